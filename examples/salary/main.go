@@ -10,7 +10,7 @@ import (
 )
 
 func main() {
-	ds := dataset.NewDataSet[float32](2)
+	ds := dataset.NewDataSet[float32](0, dataset.WithTarget("Salary"))
 	if err := ds.LoadCsv("../dataset/Salary_dataset.csv", ','); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to load dataset : %v", err)
 		return
@@ -10,8 +10,7 @@ import (
 )
 
 func main() {
-	const pref_idx = 5
-	ds := dataset.NewDataSet[float32](pref_idx)
+	ds := dataset.NewDataSet[float32](0, dataset.WithTarget("Performance Index"))
 
 	if err := ds.LoadCsv("../dataset/Student_Performance.csv", ','); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to load csv : %v", err)
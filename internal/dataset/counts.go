@@ -0,0 +1,97 @@
+package dataset
+
+import (
+	"fmt"
+	"slices"
+)
+
+type ValueCount struct {
+	Value      DataCell // nil for empty cells
+	Count      int
+	Proportion float64 // Count over the number of rows
+}
+
+// Frequency of every distinct value of the column, the most frequent first. Ties keep their order of appearance
+func (ds *DataSet[T]) ValueCounts(column string) ([]ValueCount, error) {
+	j := ds.ColumnIndex(column)
+	if j == -1 {
+		return nil, fmt.Errorf("DataSet.ValueCounts : column %q not found", column)
+	}
+
+	lookup := make(map[cell_key[T]]int)
+	var counts []ValueCount
+
+	for s := range ds.Samples() {
+		c := s.At(j)
+		k, found := lookup[key_of[T](c)]
+		if !found {
+			k = len(counts)
+			lookup[key_of[T](c)] = k
+			counts = append(counts, ValueCount{Value: c})
+		}
+		counts[k].Count++
+	}
+
+	total := float64(ds.Size())
+	for k := range counts {
+		counts[k].Proportion = float64(counts[k].Count) / total
+	}
+
+	slices.SortStableFunc(counts, func(a, b ValueCount) int {
+		return b.Count - a.Count
+	})
+
+	return counts, nil
+}
+
+// Two way frequency table. Rows and Cols hold the distinct values of each column in order of appearance
+type Crosstab struct {
+	Rows   []DataCell
+	Cols   []DataCell
+	Counts [][]int // Counts[r][c] rows holding Rows[r] and Cols[c]
+}
+
+func (ds *DataSet[T]) Crosstab(row_column string, col_column string) (Crosstab, error) {
+	var table Crosstab
+
+	indices, err := ds.column_indices("Crosstab", []string{row_column, col_column})
+	if err != nil {
+		return table, err
+	}
+
+	row_lookup := make(map[cell_key[T]]int)
+	col_lookup := make(map[cell_key[T]]int)
+	type pair struct{ r, c int }
+	var pairs []pair
+
+	for s := range ds.Samples() {
+		a, b := s.At(indices[0]), s.At(indices[1])
+
+		r, found := row_lookup[key_of[T](a)]
+		if !found {
+			r = len(table.Rows)
+			row_lookup[key_of[T](a)] = r
+			table.Rows = append(table.Rows, a)
+		}
+
+		c, found := col_lookup[key_of[T](b)]
+		if !found {
+			c = len(table.Cols)
+			col_lookup[key_of[T](b)] = c
+			table.Cols = append(table.Cols, b)
+		}
+
+		pairs = append(pairs, pair{r, c})
+	}
+
+	table.Counts = make([][]int, len(table.Rows))
+	for r := range table.Counts {
+		table.Counts[r] = make([]int, len(table.Cols))
+	}
+
+	for _, p := range pairs {
+		table.Counts[p.r][p.c]++
+	}
+
+	return table, nil
+}
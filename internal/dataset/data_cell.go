@@ -1,6 +1,11 @@
 package dataset
 
 import (
+	"cmp"
+	"strconv"
+	"strings"
+	"time"
+
 	"golang.org/x/exp/constraints"
 )
 
@@ -16,6 +21,14 @@ func (c *StrDataCell) IsReal() bool {
 	return false
 }
 
+type TimeDataCell struct {
+	Value time.Time
+}
+
+func (c *TimeDataCell) IsReal() bool {
+	return false
+}
+
 type RealDataCell[T constraints.Float] struct {
 	Value T
 }
@@ -23,3 +36,57 @@ type RealDataCell[T constraints.Float] struct {
 func (c *RealDataCell[T]) IsReal() bool {
 	return true
 }
+
+// Comparable representation of a cell, so that cells holding the same value share a key
+type cell_key[T constraints.Float] struct {
+	kind uint8 // 0 empty, 1 real, 2 string, 3 time
+	real T
+	str  string
+	time int64 // unix nanoseconds
+}
+
+func key_of[T constraints.Float](c DataCell) cell_key[T] {
+	switch v := c.(type) {
+	case *RealDataCell[T]:
+		return cell_key[T]{kind: 1, real: v.Value}
+	case *StrDataCell:
+		return cell_key[T]{kind: 2, str: v.Value}
+	case *TimeDataCell:
+		return cell_key[T]{kind: 3, time: v.Value.UnixNano()}
+	}
+	return cell_key[T]{}
+}
+
+// Orders reals numerically, then strings lexicographically, then times chronologically. Empty cells come last
+func compare_cells[T constraints.Float](a, b DataCell) int {
+	ka, kb := key_of[T](a), key_of[T](b)
+
+	switch {
+	case ka.kind == kb.kind && ka.kind == 1:
+		return cmp.Compare(ka.real, kb.real)
+	case ka.kind == kb.kind && ka.kind == 3:
+		return cmp.Compare(ka.time, kb.time)
+	case ka.kind == kb.kind:
+		return strings.Compare(ka.str, kb.str)
+	case ka.kind == 0:
+		return 1
+	case kb.kind == 0:
+		return -1
+	}
+	return cmp.Compare(ka.kind, kb.kind)
+}
+
+// Appends an unambiguous encoding of the key, so that several keys can be joined into a map key
+func (k cell_key[T]) write(sb *strings.Builder) {
+	sb.WriteByte('0' + k.kind)
+	switch k.kind {
+	case 1:
+		// -0 and 0 hold the same value
+		sb.WriteString(strconv.FormatFloat(float64(k.real+0), 'g', -1, 64))
+	case 2:
+		sb.WriteString(strconv.Quote(k.str))
+	case 3:
+		sb.WriteString(strconv.FormatInt(k.time, 10))
+	}
+	sb.WriteByte(0)
+}
@@ -2,6 +2,7 @@ package dataset
 
 import (
 	"fmt"
+	"iter"
 
 	"golang.org/x/exp/constraints"
 )
@@ -12,9 +13,15 @@ type DataSample[T constraints.Float] struct {
 	// curr_feat int
 }
 
+// Sparse features only cost their non zero entries. v must hold one value per feature
 func (s *DataSample[T]) DotProduct(v []T) (T, error) {
+	if len(v) != s.owner.FeatCount() {
+		return 0.0, fmt.Errorf("DataSample.DotProduct : %d values provided for %d features", len(v), s.owner.FeatCount())
+	}
+
 	var sum T
-	for i := range v {
+	dense := s.owner.dense_feat_count()
+	for i := range dense {
 		f := s.owner.GetFeat(s.row, i)
 		if f == nil {
 			return 0.0, fmt.Errorf("DataSample.DotProduct : dataset has empty cell <row: %d, feat: %d>", s.row, i)
@@ -22,9 +29,47 @@ func (s *DataSample[T]) DotProduct(v []T) (T, error) {
 		sum += *f * v[i]
 	}
 
+	for i, x := range s.sparse_feats(dense) {
+		sum += x * v[i]
+	}
+
 	return sum, nil
 }
 
+// Non zero sparse features of the sample, by feature index
+func (s *DataSample[T]) sparse_feats(offset int) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		if s.owner.sparse_feats == nil {
+			return
+		}
+
+		indices, values := s.owner.sparse_feats.Row(s.owner.phys_row(s.row))
+		for k, j := range indices {
+			if !yield(offset+j, values[k]) {
+				return
+			}
+		}
+	}
+}
+
+// Index and value of every non zero feature, in increasing index order. Empty cells are skipped
+func (s *DataSample[T]) NonZeroFeats() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		dense := s.owner.dense_feat_count()
+		for i := range dense {
+			if f := s.owner.GetFeat(s.row, i); f != nil && *f != 0 && !yield(i, *f) {
+				return
+			}
+		}
+
+		for i, x := range s.sparse_feats(dense) {
+			if !yield(i, x) {
+				return
+			}
+		}
+	}
+}
+
 func (s *DataSample[T]) GetSampleTest() ([]T, error) {
 	sample := make([]T, s.owner.FeatCount())
 	for i := range sample {
@@ -53,20 +98,55 @@ func (s *DataSample[T]) GetSampleTestNoErr(placeholder T) []T {
 	return sample
 }
 
+func (s *DataSample[T]) FeatCount() int {
+	return s.owner.FeatCount()
+}
+
 func (s *DataSample[T]) GetFeat(i int) *T {
 	return s.owner.GetFeat(s.row, i)
 }
 
+// Raw cell of the j-th column, nil when empty or out of range
+func (s *DataSample[T]) At(j int) DataCell {
+	if j < 0 || j >= len(s.owner.headers) {
+		return nil
+	}
+	return s.owner.at(s.row, j)
+}
+
 func (s *DataSample[T]) GetRow() int {
 	return s.row
 }
 
 func (s *DataSample[T]) GetTarget() *T {
-	trg := s.owner.at(s.row, int(s.owner.trg_col_idx))
-	if trg.IsReal() {
-		t, _ := trg.(*RealDataCell[T])
+	// empty targets are nil cells
+	if t, ok := s.owner.at(s.row, int(s.owner.trg_col_idx)).(*RealDataCell[T]); ok {
 		return &t.Value
 	}
 
 	return nil
 }
+
+// Value of every target, in the order of DataSet.TargetNames. Empty and non real targets are nil
+func (s *DataSample[T]) GetTargets() []*T {
+	targets := s.owner.targets()
+	values := make([]*T, len(targets))
+	for k, j := range targets {
+		if c, ok := s.owner.at(s.row, j).(*RealDataCell[T]); ok {
+			values[k] = &c.Value
+		}
+	}
+	return values
+}
+
+// Sample weight of the row. 1 when the dataset is not weighted or the weight cell is empty
+func (s *DataSample[T]) GetWeight() T {
+	if !s.owner.Weighted() {
+		return 1
+	}
+
+	if c, ok := s.owner.at(s.row, s.owner.weight_column()).(*RealDataCell[T]); ok {
+		return c.Value
+	}
+	return 1
+}
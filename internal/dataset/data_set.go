@@ -7,14 +7,15 @@ import (
 	"io"
 	"iter"
 	"math"
-	"math/rand"
-	"os"
+	"math/rand/v2"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bleak-and-bare/machine_learning/internal/iterable"
 	"github.com/bleak-and-bare/machine_learning/internal/maths"
+	"github.com/bleak-and-bare/machine_learning/internal/maths/sparse"
 	"golang.org/x/exp/constraints"
 )
 
@@ -29,23 +30,97 @@ type DataSet[T constraints.Float] struct {
 	headers           []header_t
 	real_feat_indices []int
 	trg_col_idx       uint32
-	datas             []DataCell // row major
+	trg_cols          []int          // every target column when there are several, trg_col_idx being the first one
+	trg_col_names     []string       // resolved into trg_col_idx and trg_cols once the headers are read
+	weight_idx        int            // sample weight column + 1, 0 when rows are not weighted
+	weight_col_name   string         // resolved into weight_idx once the headers are read
+	time_layouts      []string       // layouts tried on the non numeric cells while loading
+	datas             []DataCell     // row major
+	sparse_feats      *sparse.CSR[T] // features following the dense ones, one row per storage row. See AddSparseFeatures
+	rows              []int          // row indices of an index view, nil when rows are read in storage order
 }
 
-func NewDataSet[T constraints.Float](trg_col_idx uint32) DataSet[T] {
+type options struct {
+	targets      []string
+	weights      string
+	time_layouts []string
+}
+
+type Option func(*options)
+
+// Picks the target column by name once the headers are read, instead of by position
+func WithTarget(name string) Option {
+	return func(o *options) {
+		o.targets = []string{name}
+	}
+}
+
+// Picks several target columns by name once the headers are read. See SetTargets
+func WithTargets(names ...string) Option {
+	return func(o *options) {
+		o.targets = names
+	}
+}
+
+// Layouts of time.Parse tried in order on the non numeric cells while loading. Matching cells become TimeDataCell
+func WithTimeLayouts(layouts ...string) Option {
+	return func(o *options) {
+		o.time_layouts = layouts
+	}
+}
+
+// Picks the sample weight column by name once the headers are read. See SetWeightColumn
+func WithWeights(name string) Option {
+	return func(o *options) {
+		o.weights = name
+	}
+}
+
+func NewDataSet[T constraints.Float](trg_col_idx uint32, opts ...Option) DataSet[T] {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	var ds DataSet[T]
 	ds.trg_col_idx = trg_col_idx
+	ds.trg_col_names = o.targets
+	ds.weight_col_name = o.weights
+	ds.time_layouts = o.time_layouts
 	ds.min_range = 0.0
 	ds.max_range = 1.0
 	return ds
 }
 
+// Called by the loaders once headers and datas are read
+func (ds *DataSet[T]) finish_load() error {
+	ds.real_feat_indices = make([]int, len(ds.headers)-1)
+	ds.update_feat_indices()
+
+	if ds.trg_col_names != nil {
+		if err := ds.SetTargets(ds.trg_col_names...); err != nil {
+			return err
+		}
+	}
+
+	if ds.weight_col_name != "" {
+		return ds.SetWeightColumn(ds.weight_col_name)
+	}
+	return nil
+}
+
 // This method duplicate every underlying data containers
 func (ds *DataSet[T]) Copy() DataSet[T] {
 	copy := *ds
 	copy.headers = slices.Clone(ds.headers)
 	copy.real_feat_indices = slices.Clone(ds.real_feat_indices)
 	copy.datas = slices.Clone(ds.datas)
+	copy.rows = slices.Clone(ds.rows)
+	copy.trg_cols = slices.Clone(ds.trg_cols)
+	if ds.sparse_feats != nil {
+		sparse_feats := ds.sparse_feats.Clone()
+		copy.sparse_feats = &sparse_feats
+	}
 	return copy
 }
 
@@ -65,23 +140,201 @@ func (ds *DataSet[T]) MapColumnAt(i int, cb func(DataCell) DataCell) *DataSet[T]
 
 // This method can be applied on dropped column
 func (ds *DataSet[T]) MapColumn(name string, cb func(DataCell) DataCell) *DataSet[T] {
-	return ds.MapColumnAt(slices.IndexFunc(ds.headers, func(h header_t) bool {
+	return ds.MapColumnAt(ds.ColumnIndex(name), cb)
+}
+
+// Returns the index of the column, -1 if not found. Dropped columns keep their index
+func (ds *DataSet[T]) ColumnIndex(name string) int {
+	return slices.IndexFunc(ds.headers, func(h header_t) bool {
 		return h.name == name
-	}), cb)
+	})
+}
+
+/*
+Append a column holding one cell per row of the dataset.
+On a view (Extract, Select...) the rows of the view are first copied into a new storage :
+the parent dataset does not see the column, while cells are still shared with it
+*/
+func (ds *DataSet[T]) AddColumn(name string, values []DataCell) error {
+	return ds.add_columns("DataSet.AddColumn", []string{name}, [][]DataCell{values})
+}
+
+// Append several columns at once, values[k] holding the cells of names[k]. Nothing is added when one of them is invalid.
+// Same storage rules as AddColumn
+func (ds *DataSet[T]) AddColumns(names []string, values [][]DataCell) error {
+	return ds.add_columns("DataSet.AddColumns", names, values)
+}
+
+func (ds *DataSet[T]) add_columns(method string, names []string, values [][]DataCell) error {
+	if len(names) != len(values) {
+		return fmt.Errorf("%s : %d names provided for %d columns", method, len(names), len(values))
+	}
+
+	for k, name := range names {
+		if ds.ColumnIndex(name) != -1 || slices.Index(names, name) != k {
+			return fmt.Errorf("%s : column %q already exists", method, name)
+		}
+
+		if len(values[k]) != int(ds.Size()) {
+			return fmt.Errorf("%s : %d values provided for %d rows", method, len(values[k]), ds.Size())
+		}
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	first := len(ds.headers)
+	cols := make([]int, first+len(names))
+	for j := range cols {
+		cols[j] = -1
+		if j < first {
+			cols[j] = j
+		}
+	}
+	ds.relayout(cols)
+
+	ds.headers = slices.Clip(ds.headers)
+	for _, name := range names {
+		ds.headers = append(ds.headers, header_t{name, true})
+	}
+	ds.real_feat_indices = make([]int, len(ds.headers)-1)
+	ds.update_feat_indices()
+
+	for k, column := range values {
+		for i, v := range column {
+			ds.set_at(i, first+k, v)
+		}
+	}
+
+	return nil
+}
+
+// Views sharing their headers with ds, like the ones returned by Extract, see the new name as well
+func (ds *DataSet[T]) RenameColumn(name string, new_name string) error {
+	j := ds.ColumnIndex(name)
+	if j == -1 {
+		return fmt.Errorf("DataSet.RenameColumn : column %q not found", name)
+	}
+
+	if name == new_name {
+		return nil
+	}
+
+	if ds.ColumnIndex(new_name) != -1 {
+		return fmt.Errorf("DataSet.RenameColumn : column %q already exists", new_name)
+	}
+
+	ds.headers[j].name = new_name
+	return nil
+}
+
+/*
+Reorder the columns, which also orders the features and so the parameters of the models fit on the dataset.
+names must list every column returned by GetColumnNames once, dropped columns are moved after them.
+Same storage rules as AddColumn
+*/
+func (ds *DataSet[T]) ReorderColumns(names []string) error {
+	used := ds.GetColumnNames()
+	if len(names) != len(used) {
+		return fmt.Errorf("DataSet.ReorderColumns : %d columns provided, %d expected", len(names), len(used))
+	}
+
+	cols := make([]int, 0, len(ds.headers))
+	for _, name := range names {
+		j := ds.ColumnIndex(name)
+		if j == -1 || !ds.headers[j].used {
+			return fmt.Errorf("DataSet.ReorderColumns : column %q not found", name)
+		}
+
+		if slices.Contains(cols, j) {
+			return fmt.Errorf("DataSet.ReorderColumns : column %q listed twice", name)
+		}
+		cols = append(cols, j)
+	}
+
+	for j, h := range ds.headers {
+		if !h.used {
+			cols = append(cols, j)
+		}
+	}
+
+	ds.remap_columns(cols)
+	return nil
+}
+
+// Relayout the storage with the existing columns cols, in that order, and update headers, targets and weights accordingly.
+// Targets and the weight column must be kept
+func (ds *DataSet[T]) remap_columns(cols []int) {
+	ds.relayout(cols)
+
+	headers := make([]header_t, len(cols))
+	for k, j := range cols {
+		headers[k] = ds.headers[j]
+	}
+	ds.trg_col_idx = uint32(slices.Index(cols, int(ds.trg_col_idx)))
+	if ds.trg_cols != nil {
+		trg_cols := make([]int, len(ds.trg_cols))
+		for k, j := range ds.trg_cols {
+			trg_cols[k] = slices.Index(cols, j)
+		}
+		ds.trg_cols = trg_cols
+	}
+	if ds.Weighted() {
+		ds.weight_idx = slices.Index(cols, ds.weight_column()) + 1
+	}
+	ds.headers = headers
+	ds.real_feat_indices = make([]int, len(ds.headers)-1)
+	ds.update_feat_indices()
+}
+
+// Copy the rows of the dataset into a new storage whose k-th column is the cols[k] one, or empty when cols[k] is -1.
+// headers must be updated by the caller afterward
+func (ds *DataSet[T]) relayout(cols []int) {
+	start, end := ds.min_bound(), ds.max_bound()
+	datas := make([]DataCell, 0, (end-start)*len(cols))
+
+	if ds.sparse_feats != nil {
+		sparse_feats := ds.sparse_feats.SelectRows(ds.phys_rows())
+		ds.sparse_feats = &sparse_feats
+	}
+
+	for i := start; i < end; i++ {
+		for _, j := range cols {
+			if j < 0 {
+				datas = append(datas, nil)
+			} else {
+				datas = append(datas, ds.at(i, j))
+			}
+		}
+	}
+
+	ds.datas = datas
+	ds.rows = nil
+	ds.min_range = 0.0
+	ds.max_range = 1.0
+}
+
+// storage row of the i-th row
+func (ds *DataSet[T]) phys_row(i int) int {
+	if ds.rows != nil {
+		return ds.rows[i]
+	}
+	return i
 }
 
 func (ds *DataSet[T]) set_at(i, j int, v DataCell) {
-	ds.datas[i*len(ds.headers)+j] = v
+	ds.datas[ds.phys_row(i)*len(ds.headers)+j] = v
 }
 
 func (ds *DataSet[T]) at(i, j int) DataCell {
-	return ds.datas[i*len(ds.headers)+j]
+	return ds.datas[ds.phys_row(i)*len(ds.headers)+j]
 }
 
 func (ds *DataSet[T]) update_feat_indices() {
 	var idx int
 	for i := range ds.headers {
-		if i != int(ds.trg_col_idx) && ds.headers[i].used {
+		if ds.headers[i].used && !ds.is_target(i) && i != ds.weight_column() {
 			ds.real_feat_indices[idx] = i
 			idx++
 		}
@@ -92,8 +345,9 @@ func (ds *DataSet[T]) update_feat_indices() {
 	}
 }
 
+// The previous targets become features. On a view, the parent dataset keeps its targets
 func (ds *DataSet[T]) SetTargetCol(i int) error {
-	if i == int(ds.trg_col_idx) {
+	if i == int(ds.trg_col_idx) && ds.trg_cols == nil {
 		return nil
 	}
 
@@ -105,9 +359,28 @@ func (ds *DataSet[T]) SetTargetCol(i int) error {
 		return errors.New("Can not set unused column as target")
 	}
 
+	if i == ds.weight_column() {
+		return errors.New("Can not set the sample weight column as target")
+	}
+
+	ds.trg_col_idx = uint32(i)
+	ds.trg_cols = nil
+	ds.trg_col_names = nil
+	// views share real_feat_indices with their parent
+	ds.real_feat_indices = make([]int, len(ds.headers)-1)
+	ds.update_feat_indices()
+
 	return nil
 }
 
+func (ds *DataSet[T]) SetTarget(name string) error {
+	i := ds.ColumnIndex(name)
+	if i == -1 {
+		return fmt.Errorf("Column %q not found", name)
+	}
+	return ds.SetTargetCol(i)
+}
+
 func (ds *DataSet[T]) FillEmpties(placeholder T) {
 	for i := range ds.datas {
 		if ds.datas[i] == nil {
@@ -116,7 +389,12 @@ func (ds *DataSet[T]) FillEmpties(placeholder T) {
 	}
 }
 
+// Count of features, sparse ones included
 func (ds *DataSet[T]) FeatCount() int {
+	return ds.dense_feat_count() + ds.SparseFeatCount()
+}
+
+func (ds *DataSet[T]) dense_feat_count() int {
 	if end := slices.Index(ds.real_feat_indices, -1); end != -1 {
 		return end
 	}
@@ -124,10 +402,14 @@ func (ds *DataSet[T]) FeatCount() int {
 }
 
 func (ds *DataSet[T]) GetFeat(row int, feat int) *T {
-	if feat >= len(ds.real_feat_indices) || feat < 0 || row < ds.min_bound() || row >= ds.max_bound() {
+	if feat < 0 || row < ds.min_bound() || row >= ds.max_bound() {
 		return nil
 	}
 
+	if dense := ds.dense_feat_count(); feat >= dense {
+		return ds.sparse_feat(row, feat-dense)
+	}
+
 	idx := ds.real_feat_indices[feat]
 	if idx == -1 {
 		return nil
@@ -142,7 +424,7 @@ func (ds *DataSet[T]) GetFeat(row int, feat int) *T {
 }
 
 func (ds *DataSet[T]) DropColumnAt(idx uint8) *DataSet[T] {
-	if idx != uint8(ds.trg_col_idx) && int(idx) < len(ds.headers) {
+	if int(idx) < len(ds.headers) && !ds.is_target(int(idx)) && int(idx) != ds.weight_column() {
 		ds.headers[idx].used = false
 		ds.update_feat_indices()
 	}
@@ -150,10 +432,7 @@ func (ds *DataSet[T]) DropColumnAt(idx uint8) *DataSet[T] {
 }
 
 func (ds *DataSet[T]) DropColumn(name string) *DataSet[T] {
-	i := slices.IndexFunc(ds.headers, func(h header_t) bool {
-		return h.name == name
-	})
-	return ds.DropColumnAt(uint8(i))
+	return ds.DropColumnAt(uint8(ds.ColumnIndex(name)))
 }
 
 func (ds *DataSet[T]) GetColumnNames() []string {
@@ -166,19 +445,20 @@ func (ds *DataSet[T]) GetColumnNames() []string {
 	return cols
 }
 
+// Distinct values of the j-th column in order of appearance, compared by value. Empty cells give one nil entry
 func (ds *DataSet[T]) UniqueAt(j int) []DataCell {
 	if j < 0 || j >= len(ds.headers) {
 		return nil
 	}
 
 	max_bound := ds.max_bound()
-	lookup := make(map[DataCell]struct{})
+	lookup := make(map[cell_key[T]]struct{})
 	var uniques []DataCell
 
 	for i := ds.min_bound(); i < max_bound; i++ {
 		c := ds.at(i, j)
-		if _, found := lookup[c]; !found {
-			lookup[c] = struct{}{}
+		if _, found := lookup[key_of[T](c)]; !found {
+			lookup[key_of[T](c)] = struct{}{}
 			uniques = append(uniques, c)
 		}
 	}
@@ -187,9 +467,7 @@ func (ds *DataSet[T]) UniqueAt(j int) []DataCell {
 }
 
 func (ds *DataSet[T]) Unique(column string) []DataCell {
-	return ds.UniqueAt(slices.IndexFunc(ds.headers, func(h header_t) bool {
-		return h.name == column
-	}))
+	return ds.UniqueAt(ds.ColumnIndex(column))
 }
 
 // This method returns a DataSet with same reference to the underlying datas
@@ -206,8 +484,38 @@ func (ds *DataSet[T]) Extract(min_range float32, max_range float32) (*DataSet[T]
 	return &new_ds, nil
 }
 
+// Returns a view on the given rows, in the given order. Indices are relative to ds and may repeat.
+// The view shares the underlying datas with ds
+func (ds *DataSet[T]) Select(rows []int) (*DataSet[T], error) {
+	size := int(ds.Size())
+	start := ds.min_bound()
+
+	phys := make([]int, len(rows))
+	for i, r := range rows {
+		if r < 0 || r >= size {
+			return nil, fmt.Errorf("DataSet.Select : row %d out of range", r)
+		}
+		phys[i] = ds.phys_row(start + r)
+	}
+
+	new_ds := *ds
+	new_ds.rows = phys
+	new_ds.min_range = 0.0
+	new_ds.max_range = 1.0
+
+	return &new_ds, nil
+}
+
 // returns real samples count
 func (ds *DataSet[T]) raw_count() uint32 {
+	if ds.rows != nil {
+		return uint32(len(ds.rows))
+	}
+
+	// nothing loaded yet
+	if len(ds.headers) == 0 {
+		return 0
+	}
 	return uint32(len(ds.datas) / len(ds.headers))
 }
 
@@ -271,6 +579,11 @@ func (ds *DataSet[T]) Head(max uint32) {
 				if l > max_lengths[j] {
 					max_lengths[j] = l
 				}
+			case *TimeDataCell:
+				l := len(c.Value.Format(time.RFC3339))
+				if l > max_lengths[j] {
+					max_lengths[j] = l
+				}
 			}
 		}
 	}
@@ -300,6 +613,8 @@ func (ds *DataSet[T]) Head(max uint32) {
 				str = fmt.Sprintf("%.3f", c.Value)
 			case *StrDataCell:
 				str = c.Value
+			case *TimeDataCell:
+				str = c.Value.Format(time.RFC3339)
 			}
 
 			fmt.Printf("%v%v%v| ", str, strings.Repeat(" ", max_lengths[j]-len(str)), tab)
@@ -312,10 +627,14 @@ func (ds *DataSet[T]) Dump() {
 	ds.Head(ds.raw_count())
 }
 
+// A leading UTF-8 byte order mark is skipped
 func (ds *DataSet[T]) LoadCsvReader(input_reader io.Reader, delim rune) error {
-	reader := csv.NewReader(input_reader)
+	reader := csv.NewReader(skip_bom(input_reader))
 	reader.Comma = delim
+	return ds.load_csv(reader)
+}
 
+func (ds *DataSet[T]) load_csv(reader *csv.Reader) error {
 	first_line := true
 	for {
 		cols, err := reader.Read()
@@ -339,14 +658,7 @@ func (ds *DataSet[T]) LoadCsvReader(input_reader io.Reader, delim rune) error {
 					break
 				}
 
-				if c, err := strconv.ParseFloat(col, 64); err == nil {
-					concrete := T(c)
-					ds.datas = append(ds.datas, &RealDataCell[T]{concrete})
-				} else if len(col) > 0 {
-					ds.datas = append(ds.datas, &StrDataCell{col})
-				} else {
-					ds.datas = append(ds.datas, nil)
-				}
+				ds.datas = append(ds.datas, ds.parse_cell(col))
 			}
 
 			r := len(ds.headers) - len(cols)
@@ -358,14 +670,29 @@ func (ds *DataSet[T]) LoadCsvReader(input_reader io.Reader, delim rune) error {
 		}
 	}
 
-	ds.real_feat_indices = make([]int, len(ds.headers)-1)
-	ds.update_feat_indices()
+	return ds.finish_load()
+}
 
-	return nil
+// Real cell if the text is a number, time cell if it matches a time layout, string cell otherwise. Empty text gives an empty cell
+func (ds *DataSet[T]) parse_cell(text string) DataCell {
+	if c, err := strconv.ParseFloat(text, 64); err == nil {
+		return &RealDataCell[T]{T(c)}
+	}
+
+	if len(text) == 0 {
+		return nil
+	}
+
+	if t, ok := parse_time(text, ds.time_layouts); ok {
+		return &TimeDataCell{t}
+	}
+
+	return &StrDataCell{text}
 }
 
+// gzip and bzip2 files are decompressed on the fly
 func (ds *DataSet[T]) LoadCsv(path string, delim rune) error {
-	file, err := os.Open(path)
+	file, err := open_input(path)
 	if err != nil {
 		return err
 	}
@@ -413,13 +740,28 @@ func (ds *DataSet[T]) TargetVariance() float64 {
 }
 
 func (ds *DataSet[T]) Shuffle() *DataSet[T] {
+	return ds.ShuffleWith(rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())))
+}
+
+// Same as Shuffle but draws the permutation from r
+func (ds *DataSet[T]) ShuffleWith(r *rand.Rand) *DataSet[T] {
+	// sparse rows can not be swapped in place, shuffle an index view instead
+	if ds.rows == nil && ds.sparse_feats != nil {
+		ds.rows = make([]int, ds.raw_count())
+		for i := range ds.rows {
+			ds.rows[i] = i
+		}
+	}
+
 	start := ds.min_bound()
 	cols := len(ds.headers)
 	real_size := int(ds.raw_count())
 
-	rand.Shuffle(int(ds.Size()), func(i, j int) {
+	r.Shuffle(int(ds.Size()), func(i, j int) {
 		shift_i, shift_j := start+i, start+j
-		if shift_i < real_size && shift_j < real_size {
+		if ds.rows != nil {
+			ds.rows[shift_i], ds.rows[shift_j] = ds.rows[shift_j], ds.rows[shift_i]
+		} else if shift_i < real_size && shift_j < real_size {
 			for k := range cols {
 				ds.datas[shift_i*cols+k], ds.datas[shift_j*cols+k] = ds.datas[shift_j*cols+k], ds.datas[shift_i*cols+k]
 			}
@@ -450,9 +792,7 @@ func (ds *DataSet[T]) ColumnAt(j int) iter.Seq[DataCell] {
 }
 
 func (ds *DataSet[T]) Column(name string) iter.Seq[DataCell] {
-	return ds.ColumnAt(slices.IndexFunc(ds.headers, func(h header_t) bool {
-		return h.name == name
-	}))
+	return ds.ColumnAt(ds.ColumnIndex(name))
 }
 
 func (ds *DataSet[T]) TargetColumn() iter.Seq[DataCell] {
@@ -1,11 +1,20 @@
 package dataset_test
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"github.com/bleak-and-bare/machine_learning/internal/maths/sparse"
 )
 
 func mock_data_set() (dataset.DataSet[float32], error) {
@@ -43,6 +52,17 @@ func TestDataSet_LoadCsv(t *testing.T) {
 	}
 }
 
+func TestDataSet_Empty(t *testing.T) {
+	ds := dataset.NewDataSet[float32](0)
+	if ds.Size() != 0 {
+		t.Errorf("A dataset with no column should be empty, got %d rows", ds.Size())
+	}
+
+	for range ds.Samples() {
+		t.Error("A dataset with no column has no sample")
+	}
+}
+
 func TestDataSet_DropColumnAt(t *testing.T) {
 	ds, _ := mock_data_set()
 	cols := ds.DropColumnAt(0).GetColumnNames()
@@ -89,3 +109,1207 @@ func TestDataSet_Extract(t *testing.T) {
 		t.Errorf("Should not be able to extract : invalid range")
 	}
 }
+
+func TestDataSet_Select(t *testing.T) {
+	ds, _ := mock_data_set()
+	test, _ := ds.Extract(0.5, 1.0)
+
+	view, err := test.Select([]int{4, 0, 0})
+	if err != nil {
+		t.Errorf("Should be able to select rows : %v", err)
+		t.FailNow()
+	}
+
+	if view.Size() != 3 {
+		t.Errorf("Invalid view size : %d != 3", view.Size())
+	}
+
+	var years []float32
+	for s := range view.Samples() {
+		years = append(years, *s.GetFeat(1))
+	}
+
+	if !slices.Equal(years, []float32{2.1, 3.0, 3.0}) {
+		t.Errorf("Wrong rows selected : %v", years)
+	}
+
+	if _, err := test.Select([]int{5}); err == nil {
+		t.Error("Should not be able to select a row outside of the view")
+	}
+}
+
+func TestDataSet_AddColumn(t *testing.T) {
+	ds, _ := mock_data_set()
+	test, _ := ds.Extract(0.5, 1.0)
+
+	bonus := make([]dataset.DataCell, test.Size())
+	for i := range bonus {
+		bonus[i] = &dataset.RealDataCell[float32]{Value: float32(i)}
+	}
+
+	if err := test.AddColumn("Bonus", bonus); err != nil {
+		t.Errorf("Should be able to add a column : %v", err)
+		t.FailNow()
+	}
+
+	if cols := test.GetColumnNames(); !slices.Equal(cols, []string{"", "YearsExperience", "Salary", "Bonus"}) {
+		t.Errorf("Wrong columns after adding a column : %v", cols)
+	}
+
+	for s := range test.Samples() {
+		if *s.GetFeat(2) != float32(s.GetRow()) {
+			t.Errorf("Wrong added value at row %d : %v", s.GetRow(), *s.GetFeat(2))
+		}
+	}
+
+	// the view now owns its storage : replacing its cells leaves the parent untouched
+	test.MapColumn("YearsExperience", func(dataset.DataCell) dataset.DataCell {
+		return &dataset.RealDataCell[float32]{Value: -1}
+	})
+
+	for s := range ds.Samples() {
+		if *s.GetFeat(1) == -1 {
+			t.Errorf("Parent dataset modified through a detached view at row %d", s.GetRow())
+		}
+	}
+
+	if ds.Size() != 10 || slices.Contains(ds.GetColumnNames(), "Bonus") {
+		t.Error("Parent dataset should not see columns added on a view")
+	}
+
+	if err := test.AddColumn("Salary", make([]dataset.DataCell, 5)); err == nil {
+		t.Error("Should not be able to add an existing column")
+	}
+
+	if err := test.AddColumn("Other", make([]dataset.DataCell, 4)); err == nil {
+		t.Error("Should not be able to add a column with a wrong number of rows")
+	}
+	// a single invalid column leaves the dataset untouched
+	err := test.AddColumns([]string{"First", "Bonus"}, [][]dataset.DataCell{make([]dataset.DataCell, 5), make([]dataset.DataCell, 5)})
+	if err == nil || slices.Contains(test.GetColumnNames(), "First") {
+		t.Errorf("AddColumns should add nothing when a name is taken : %v", test.GetColumnNames())
+	}
+
+	if err := test.AddColumns([]string{"A", "A"}, [][]dataset.DataCell{make([]dataset.DataCell, 5), make([]dataset.DataCell, 5)}); err == nil {
+		t.Error("Should not be able to add the same column twice")
+	}
+
+	if err := test.AddColumns([]string{"A", "B"}, [][]dataset.DataCell{bonus, bonus}); err != nil {
+		t.Errorf("Should be able to add several columns : %v", err)
+	}
+
+	if cols := test.GetColumnNames(); !slices.Equal(cols, []string{"", "YearsExperience", "Salary", "Bonus", "A", "B"}) || test.FeatCount() != 5 {
+		t.Errorf("Wrong columns after adding several columns : %v", cols)
+	}
+}
+
+func TestDataSet_DeriveColumn(t *testing.T) {
+	ds, _ := mock_data_set()
+	test, _ := ds.Extract(0.5, 1.0)
+
+	err := test.DeriveColumn("Salary per year", func(row dataset.RowView[float32]) dataset.DataCell {
+		years, _ := row.Real("YearsExperience")
+		salary, _ := row.Real("Salary")
+		return &dataset.RealDataCell[float32]{Value: salary / years}
+	})
+
+	if err != nil {
+		t.Errorf("Should be able to derive a column : %v", err)
+		t.FailNow()
+	}
+
+	if test.Size() != 5 || test.FeatCount() != 3 {
+		t.Errorf("Invalid view after adding a column : %d rows, %d features", test.Size(), test.FeatCount())
+	}
+
+	for s := range test.Samples() {
+		if *s.GetTarget() != *s.GetFeat(1)**s.GetFeat(2) {
+			t.Errorf("Wrong derived value at row %d", s.GetRow())
+		}
+	}
+
+	if slices.Contains(ds.GetColumnNames(), "Salary per year") {
+		t.Error("Parent dataset should not see columns added on a view")
+	}
+}
+
+func TestDataSet_ReorderColumns(t *testing.T) {
+	ds, _ := mock_data_set()
+	ds.DropColumnAt(0)
+
+	if err := ds.RenameColumn("YearsExperience", "Years"); err != nil {
+		t.Errorf("Should be able to rename a column : %v", err)
+	}
+
+	if err := ds.ReorderColumns([]string{"Salary", "Years"}); err != nil {
+		t.Errorf("Should be able to reorder columns : %v", err)
+		t.FailNow()
+	}
+
+	if cols := ds.GetColumnNames(); !slices.Equal(cols, []string{"Salary", "Years"}) {
+		t.Errorf("Wrong column order : %v", cols)
+	}
+
+	for s := range ds.Samples() {
+		if s.GetRow() == 0 && (*s.GetTarget() != 39344.0 || *s.GetFeat(0) != 1.2) {
+			t.Error("Target or features lost while reordering")
+		}
+	}
+
+	if err := ds.ReorderColumns([]string{"Salary", "Salary"}); err == nil {
+		t.Error("Should not be able to list a column twice")
+	}
+}
+
+func TestDataSet_Eval(t *testing.T) {
+	ds, _ := mock_data_set()
+
+	if err := ds.Eval("`Salary per year` = Salary / YearsExperience"); err != nil {
+		t.Errorf("Should be able to evaluate an assignment : %v", err)
+		t.FailNow()
+	}
+
+	for s := range ds.Samples() {
+		if *s.GetTarget() != *s.GetFeat(1)**s.GetFeat(2) {
+			t.Errorf("Wrong evaluated value at row %d", s.GetRow())
+		}
+	}
+
+	if err := ds.Eval("bonus = Commission * 2"); err == nil {
+		t.Error("Should not be able to reference a missing column")
+	}
+
+	if err := ds.Eval("Salary * 2"); err == nil {
+		t.Error("Should require a column name")
+	}
+
+	names := dataset.NewDataSet[float32](1)
+	names.LoadCsvReader(strings.NewReader("name,y\nada,1\nbob,2\n"), ',')
+	if err := names.Eval("z = name * 2"); err == nil {
+		t.Error("Should not be able to multiply a string")
+	}
+
+	if cols := names.GetColumnNames(); !slices.Equal(cols, []string{"name", "y"}) {
+		t.Errorf("A failed evaluation should not add a column : %v", cols)
+	}
+}
+
+func TestDataSet_Where(t *testing.T) {
+	ds, _ := mock_data_set()
+
+	senior, err := ds.Where("YearsExperience >= 2 && !(Salary > 50000)")
+	if err != nil {
+		t.Errorf("Should be able to filter rows : %v", err)
+		t.FailNow()
+	}
+
+	if senior.Size() != 3 {
+		t.Errorf("Expected 3 rows, got %d", senior.Size())
+	}
+
+	for s := range senior.Samples() {
+		if *s.GetFeat(1) < 2 || *s.GetTarget() > 50000 {
+			t.Errorf("Row %d should have been filtered out", s.GetRow())
+		}
+	}
+
+	if ds.Size() != 10 {
+		t.Error("Filtering should not alter the parent dataset")
+	}
+
+	if _, err := ds.Where("Salary >"); err == nil {
+		t.Error("Should not accept an incomplete expression")
+	}
+}
+
+func TestDataSet_Filter(t *testing.T) {
+	ds, _ := mock_data_set()
+	test, _ := ds.Extract(0.5, 1.0)
+
+	high := test.Filter(func(s dataset.DataSample[float32]) bool {
+		return *s.GetTarget() > 50000
+	})
+
+	if high.Size() != 3 {
+		t.Errorf("Expected 3 rows, got %d", high.Size())
+	}
+
+	for s := range high.Samples() {
+		if *s.GetTarget() <= 50000 {
+			t.Errorf("Row %d should have been filtered out", s.GetRow())
+		}
+	}
+}
+
+func TestDataSet_SortBy(t *testing.T) {
+	ds, _ := mock_data_set()
+	ds.MapColumn("YearsExperience", func(c dataset.DataCell) dataset.DataCell {
+		if v := c.(*dataset.RealDataCell[float32]); v.Value == 2.1 {
+			return nil
+		}
+		return c
+	})
+
+	sorted, err := ds.SortBy([]string{"YearsExperience", "Salary"}, []bool{false, true})
+	if err != nil {
+		t.Errorf("Should be able to sort : %v", err)
+		t.FailNow()
+	}
+
+	var years []float32
+	var salaries []float32
+	for s := range sorted.Samples() {
+		if f := s.GetFeat(1); f != nil {
+			years = append(years, *f)
+		} else {
+			years = append(years, -1)
+		}
+		salaries = append(salaries, *s.GetTarget())
+	}
+
+	if !slices.Equal(years, []float32{3.1, 3.0, 3.0, 2.3000000000000003, 1.6, 1.4000000000000001, 1.4000000000000001, 1.2000000000000002, -1, -1}) {
+		t.Errorf("Wrong sort order : %v", years)
+	}
+
+	if salaries[8] != 43526.0 || salaries[9] != 43526.0 {
+		t.Errorf("Empty cells should come last : %v", salaries)
+	}
+
+	if _, err := ds.SortBy([]string{"Salary"}, []bool{true, false}); err == nil {
+		t.Error("Should not accept more directions than columns")
+	}
+	mixed := dataset.NewDataSet[float32](1)
+	mixed.LoadCsvReader(strings.NewReader("v,y\nb,0\n1,0\n,0\na,0\n3,0\n"), ',')
+
+	desc, err := mixed.SortBy([]string{"v"}, []bool{false})
+	if err != nil {
+		t.Errorf("Should be able to sort : %v", err)
+		t.FailNow()
+	}
+
+	var values []string
+	for s := range desc.Samples() {
+		switch v := s.At(0).(type) {
+		case *dataset.RealDataCell[float32]:
+			values = append(values, fmt.Sprint(v.Value))
+		case *dataset.StrDataCell:
+			values = append(values, v.Value)
+		default:
+			values = append(values, "NA")
+		}
+	}
+
+	if !slices.Equal(values, []string{"3", "1", "b", "a", "NA"}) {
+		t.Errorf("Reals should come before strings when descending : %v", values)
+	}
+}
+
+func TestDataSet_DropDuplicates(t *testing.T) {
+	ds, _ := mock_data_set()
+
+	unique, err := ds.DropDuplicates(nil)
+	if err != nil {
+		t.Errorf("Should be able to drop duplicates : %v", err)
+		t.FailNow()
+	}
+
+	if unique.Size() != 7 {
+		t.Errorf("Expected 7 distinct rows, got %d", unique.Size())
+	}
+
+	by_years, _ := ds.DropDuplicates([]string{"YearsExperience"})
+	if by_years.Size() != 7 {
+		t.Errorf("Expected 7 distinct experiences, got %d", by_years.Size())
+	}
+
+	if _, err := ds.DropDuplicates([]string{"Age"}); err == nil {
+		t.Error("Should not accept a missing column")
+	}
+}
+
+func TestDataSet_Unique(t *testing.T) {
+	ds, _ := mock_data_set()
+	ds.MapColumn("YearsExperience", func(c dataset.DataCell) dataset.DataCell {
+		if v := c.(*dataset.RealDataCell[float32]); v.Value == 1.6 || v.Value == 2.3000000000000003 {
+			return nil
+		}
+		return c
+	})
+
+	uniques := ds.Unique("YearsExperience")
+	if len(uniques) != 6 {
+		t.Errorf("Expected 6 distinct values, got %d", len(uniques))
+	}
+
+	if nils := slices.IndexFunc(uniques, func(c dataset.DataCell) bool { return c == nil }); nils != 2 {
+		t.Errorf("Empty cells should be listed once, at their first appearance : %d", nils)
+	}
+}
+
+func TestDataSet_ValueCounts(t *testing.T) {
+	ds, _ := mock_data_set()
+
+	counts, err := ds.ValueCounts("Salary")
+	if err != nil {
+		t.Errorf("Should be able to count values : %v", err)
+		t.FailNow()
+	}
+
+	if len(counts) != 7 {
+		t.Errorf("Expected 7 distinct values, got %d", len(counts))
+	}
+
+	var first []float32
+	for _, c := range counts[:3] {
+		if c.Count != 2 || c.Proportion != 0.2 {
+			t.Errorf("Wrong count for %v : %d, %v", c.Value, c.Count, c.Proportion)
+		}
+		first = append(first, c.Value.(*dataset.RealDataCell[float32]).Value)
+	}
+
+	if !slices.Equal(first, []float32{46206.0, 43526.0, 56643.0}) {
+		t.Errorf("Ties should keep their order of appearance : %v", first)
+	}
+
+	if _, err := ds.ValueCounts("Age"); err == nil {
+		t.Error("Should not accept a missing column")
+	}
+}
+
+func TestDataSet_Crosstab(t *testing.T) {
+	ds, _ := mock_data_set()
+	ds.Eval("senior = YearsExperience >= 2")
+	ds.Eval("high = Salary > 45000")
+
+	table, err := ds.Crosstab("senior", "high")
+	if err != nil {
+		t.Errorf("Should be able to build a crosstab : %v", err)
+		t.FailNow()
+	}
+
+	// rows : junior, senior. columns : low, high
+	expected := [][]int{{2, 2}, {3, 3}}
+	if len(table.Rows) != 2 || len(table.Cols) != 2 {
+		t.Errorf("Wrong table shape : %d x %d", len(table.Rows), len(table.Cols))
+		t.FailNow()
+	}
+
+	for r := range expected {
+		if !slices.Equal(table.Counts[r], expected[r]) {
+			t.Errorf("Wrong counts : %v", table.Counts)
+		}
+	}
+}
+
+func TestDataSet_GroupBy(t *testing.T) {
+	ds, _ := mock_data_set()
+	ds.Eval("senior = YearsExperience >= 2")
+
+	groups, err := ds.GroupBy("senior")
+	if err != nil {
+		t.Errorf("Should be able to group rows : %v", err)
+		t.FailNow()
+	}
+
+	if groups.Len() != 2 {
+		t.Errorf("Expected 2 groups, got %d", groups.Len())
+	}
+
+	stats, err := groups.Agg(
+		dataset.Aggregation{Column: "Salary", Op: dataset.AggMean},
+		dataset.Aggregation{Op: dataset.AggCount},
+		dataset.Aggregation{Column: "Salary", Op: dataset.AggMedian},
+		dataset.Aggregation{Column: "YearsExperience", Op: dataset.AggMax, Name: "max years"},
+		dataset.Aggregation{Column: "Salary", Op: dataset.AggCustom, Name: "range", Custom: func(cells []dataset.DataCell) dataset.DataCell {
+			lo, hi := float32(math.Inf(1)), float32(math.Inf(-1))
+			for _, c := range cells {
+				v := c.(*dataset.RealDataCell[float32]).Value
+				lo, hi = min(lo, v), max(hi, v)
+			}
+			return &dataset.RealDataCell[float32]{Value: hi - lo}
+		}},
+	)
+
+	if err != nil {
+		t.Errorf("Should be able to aggregate : %v", err)
+		t.FailNow()
+	}
+
+	if cols := stats.GetColumnNames(); !slices.Equal(cols, []string{"senior", "Salary_mean", "count", "Salary_median", "max years", "range"}) {
+		t.Errorf("Wrong output columns : %v", cols)
+	}
+
+	// junior rows : 39344, 46206, 37732, 46206
+	expected := [][]float32{
+		{0, 42372, 4, 42775, 1.6, 8474},
+		{1, 50063.5, 6, 50084.5, 3.1, 20259},
+	}
+
+	for s := range stats.Samples() {
+		row := append([]float32{*s.GetFeat(0), *s.GetTarget()}, s.GetSampleTestNoErr(0)[1:]...)
+		for k, v := range expected[s.GetRow()] {
+			if math.Abs(float64(row[k]-v)) > 1e-2 {
+				t.Errorf("Wrong aggregate in group %d : %v != %v", s.GetRow(), row, expected[s.GetRow()])
+				break
+			}
+		}
+	}
+
+	if _, err := groups.Agg(dataset.Aggregation{Column: "Age", Op: dataset.AggSum}); err == nil {
+		t.Error("Should not aggregate a missing column")
+	}
+}
+
+func load_csv(t *testing.T, target uint32, csv string) *dataset.DataSet[float32] {
+	ds := dataset.NewDataSet[float32](target)
+	if err := ds.LoadCsvReader(strings.NewReader(csv), ','); err != nil {
+		t.Fatalf("Failed to load CSV : %v", err)
+	}
+	return &ds
+}
+
+func TestDataSet_Join(t *testing.T) {
+	labels := load_csv(t, 1, "id,score,note\n1,10,a\n2,20,b\n4,40,d\n")
+	features := load_csv(t, 0, "id,hours,note\n1,1.5,x\n2,2.5,y\n2,3.5,z\n3,4.5,w\n")
+
+	cases := []struct {
+		kind dataset.JoinKind
+		size uint32
+	}{
+		{dataset.InnerJoin, 3},
+		{dataset.LeftJoin, 4},
+		{dataset.RightJoin, 4},
+		{dataset.OuterJoin, 5},
+	}
+
+	for _, c := range cases {
+		joined, err := labels.Join(features, dataset.JoinOptions{On: []string{"id"}, Kind: c.kind})
+		if err != nil {
+			t.Errorf("Should be able to join : %v", err)
+			t.FailNow()
+		}
+
+		if joined.Size() != c.size {
+			t.Errorf("Join %d : expected %d rows, got %d", c.kind, c.size, joined.Size())
+		}
+
+		if cols := joined.GetColumnNames(); !slices.Equal(cols, []string{"id", "score", "note_left", "hours", "note_right"}) {
+			t.Errorf("Wrong joined columns : %v", cols)
+		}
+	}
+
+	joined, _ := labels.Join(features, dataset.JoinOptions{On: []string{"id"}, Kind: dataset.OuterJoin})
+	var ids, scores []float32
+	for s := range joined.Samples() {
+		ids = append(ids, *s.GetFeat(0))
+		if trg := s.At(1); trg != nil {
+			scores = append(scores, *s.GetTarget())
+		} else {
+			scores = append(scores, -1)
+		}
+	}
+
+	if !slices.Equal(ids, []float32{1, 2, 2, 4, 3}) || !slices.Equal(scores, []float32{10, 20, 20, 40, -1}) {
+		t.Errorf("Wrong joined rows : ids %v, scores %v", ids, scores)
+	}
+
+	if _, err := labels.Join(features, dataset.JoinOptions{On: []string{"name"}}); err == nil {
+		t.Error("Should not join on a missing column")
+	}
+}
+
+func TestDataSet_Concat(t *testing.T) {
+	a := load_csv(t, 1, "x,y\n1,10\n2,20\n")
+	b := load_csv(t, 0, "y,z\n30,3\n")
+
+	rows, err := dataset.ConcatRows(a, b)
+	if err != nil {
+		t.Errorf("Should be able to concatenate rows : %v", err)
+		t.FailNow()
+	}
+
+	if cols := rows.GetColumnNames(); !slices.Equal(cols, []string{"x", "y", "z"}) || rows.Size() != 3 {
+		t.Errorf("Wrong concatenation : %v, %d rows", cols, rows.Size())
+	}
+
+	var targets []float32
+	for s := range rows.Samples() {
+		targets = append(targets, *s.GetTarget())
+	}
+	if !slices.Equal(targets, []float32{10, 20, 30}) {
+		t.Errorf("Target should stay y : %v", targets)
+	}
+
+	c := load_csv(t, 0, "w\n5\n6\n")
+	cols, err := dataset.ConcatColumns(c, a)
+	if err != nil {
+		t.Errorf("Should be able to concatenate columns : %v", err)
+		t.FailNow()
+	}
+
+	if names := cols.GetColumnNames(); !slices.Equal(names, []string{"w", "x", "y"}) || cols.FeatCount() != 2 {
+		t.Errorf("Wrong concatenation : %v", names)
+	}
+
+	if _, err := dataset.ConcatColumns(a, b); err == nil {
+		t.Error("Should not concatenate datasets of different sizes")
+	}
+
+	empty := dataset.NewDataSet[float32](1)
+	if _, err := dataset.ConcatRows(&empty, c); err == nil {
+		t.Error("Should not concatenate rows when the target of the first dataset is missing")
+	}
+}
+
+func TestDataSet_SetTarget(t *testing.T) {
+	csv := ",YearsExperience,Salary\n0,1.5,39344.0\n1,2.5,46206.0\n"
+	ds := dataset.NewDataSet[float32](0, dataset.WithTarget("Salary"))
+	if err := ds.LoadCsvReader(strings.NewReader(csv), ','); err != nil {
+		t.Errorf("Failed to load CSV : %v", err)
+		t.FailNow()
+	}
+
+	for s := range ds.Samples() {
+		if s.GetRow() == 0 && (*s.GetTarget() != 39344.0 || *s.GetFeat(1) != 1.5) {
+			t.Error("Target should be picked by name")
+		}
+	}
+
+	view, _ := ds.Extract(0.0, 1.0)
+	if err := view.SetTarget("YearsExperience"); err != nil {
+		t.Errorf("Should be able to change the target : %v", err)
+		t.FailNow()
+	}
+
+	for s := range view.Samples() {
+		if s.GetRow() == 1 && (*s.GetTarget() != 2.5 || *s.GetFeat(1) != 46206.0) {
+			t.Error("Features should follow the new target")
+		}
+	}
+
+	for s := range ds.Samples() {
+		if s.GetRow() == 1 && (*s.GetTarget() != 46206.0 || *s.GetFeat(1) != 2.5) {
+			t.Error("Parent dataset should keep its target")
+		}
+	}
+
+	if err := ds.SetTarget("Age"); err == nil {
+		t.Error("Should not accept a missing column")
+	}
+
+	missing := dataset.NewDataSet[float32](0, dataset.WithTarget("Age"))
+	if err := missing.LoadCsvReader(strings.NewReader(csv), ','); err == nil {
+		t.Error("Should fail when the target column is missing")
+	}
+}
+
+func TestDataSet_SetTargets(t *testing.T) {
+	ds, _ := mock_data_set()
+
+	if err := ds.SetTargets("Salary", ""); err != nil {
+		t.Errorf("Should be able to set several targets : %v", err)
+		t.FailNow()
+	}
+
+	if ds.FeatCount() != 1 || !slices.Equal(ds.TargetNames(), []string{"Salary", ""}) {
+		t.Errorf("Wrong layout : %d features, targets %v", ds.FeatCount(), ds.TargetNames())
+	}
+
+	for s := range ds.Samples() {
+		targets := s.GetTargets()
+		if s.GetRow() == 2 && (*targets[0] != 37732.0 || *targets[1] != 2 || *s.GetFeat(0) != 1.6) {
+			t.Error("Wrong targets or features")
+		}
+	}
+
+	second, err := ds.ForTarget(1)
+	if err != nil {
+		t.Errorf("Should be able to view a single target : %v", err)
+		t.FailNow()
+	}
+
+	if second.TargetCount() != 1 || second.FeatCount() != 1 || !slices.Equal(second.GetColumnNames(), []string{"", "YearsExperience"}) {
+		t.Errorf("Wrong single target view : %v", second.GetColumnNames())
+	}
+
+	if ds.DropColumn(""); ds.TargetCount() != 2 {
+		t.Error("Should not be able to drop a target")
+	}
+
+	if err := ds.SetTargets("Salary", "Salary"); err == nil {
+		t.Error("Should not accept a target twice")
+	}
+}
+
+func TestDataSet_SetWeightColumn(t *testing.T) {
+	ds, _ := mock_data_set()
+
+	if err := ds.SetWeightColumn("Salary"); err == nil {
+		t.Error("Should not use the target as weights")
+	}
+
+	if err := ds.SetWeightColumn(""); err != nil {
+		t.Errorf("Should be able to set the weight column : %v", err)
+		t.FailNow()
+	}
+
+	if !ds.Weighted() || ds.FeatCount() != 1 || ds.TotalWeight() != 30 {
+		t.Errorf("Wrong weighted layout : %d features, total weight %v", ds.FeatCount(), ds.TotalWeight())
+	}
+
+	if err := ds.ReorderColumns([]string{"Salary", "YearsExperience", ""}); err != nil {
+		t.Errorf("Should be able to reorder columns : %v", err)
+		t.FailNow()
+	}
+
+	for s := range ds.Samples() {
+		if s.GetRow() == 3 && (s.GetWeight() != 3 || *s.GetFeat(0) != 2.1) {
+			t.Error("Weights should follow the reordered columns")
+		}
+	}
+
+	ds.ClearWeights()
+	if ds.Weighted() || ds.FeatCount() != 2 || ds.TotalWeight() != 10 {
+		t.Error("Clearing weights should restore the feature")
+	}
+
+	var zero dataset.DataSet[float32]
+	if zero.Weighted() {
+		t.Error("The zero value dataset should not be weighted")
+	}
+
+	negative := load_csv(t, 2, "x,w,y\n1,2,3\n4,-1,6\n")
+	if err := negative.SetWeightColumn("w"); err == nil || negative.Weighted() {
+		t.Error("Should not accept negative weights")
+	}
+}
+
+// Join, ConcatRows and GroupBy keep the weights of their input
+func TestDataSet_WeightsCarried(t *testing.T) {
+	ds := load_csv(t, 2, "k,w,y\na,2,1\nb,3,2\na,0.5,3\n")
+	if err := ds.SetWeightColumn("w"); err != nil {
+		t.Fatalf("Should be able to set the weight column : %v", err)
+	}
+
+	other := load_csv(t, 1, "k,w,z\na,7,1\nb,8,2\n")
+	joined, err := ds.Join(other, dataset.JoinOptions{On: []string{"k"}})
+	if err != nil {
+		t.Errorf("Should be able to join : %v", err)
+		t.FailNow()
+	}
+
+	if !joined.Weighted() || joined.TotalWeight() != 5.5 {
+		t.Errorf("Join should keep the left weights, total weight %v", joined.TotalWeight())
+	}
+
+	rows, err := dataset.ConcatRows(ds, ds)
+	if err != nil {
+		t.Errorf("Should be able to concatenate rows : %v", err)
+		t.FailNow()
+	}
+
+	if !rows.Weighted() || rows.TotalWeight() != 11 {
+		t.Errorf("ConcatRows should keep the weights, total weight %v", rows.TotalWeight())
+	}
+
+	g, _ := ds.GroupBy("k")
+	sums, err := g.Agg(dataset.Aggregation{Column: "y", Op: dataset.AggMean})
+	if err != nil {
+		t.Errorf("Should be able to aggregate : %v", err)
+		t.FailNow()
+	}
+
+	if !sums.Weighted() || !slices.Equal(sums.Weights(), []float32{2.5, 3}) {
+		t.Errorf("GroupBy should sum the weights of each group : %v", sums.Weights())
+	}
+}
+
+func TestDataSet_SplitByTime(t *testing.T) {
+	ds := dataset.NewDataSet[float32](1)
+	str := strings.NewReader("when,y\n2024-05-01,1\n2023-01-15,2\n,3\n2024-01-01,4\n2022-12-31,5\n")
+	if err := ds.LoadCsvReader(str, ','); err != nil {
+		t.Fatalf("Failed to load CSV : %v", err)
+	}
+
+	if err := ds.ParseTimeColumn("when", "02/01/2006"); err == nil {
+		t.Error("Should not convert a column matching no layout")
+	}
+
+	if err := ds.ParseTimeColumn("when", time.DateOnly); err != nil {
+		t.Errorf("Should be able to parse times : %v", err)
+		t.FailNow()
+	}
+
+	train, test, err := ds.SplitByTime("when", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Errorf("Should be able to split by time : %v", err)
+		t.FailNow()
+	}
+
+	targets := func(ds *dataset.DataSet[float32]) []float32 {
+		var y []float32
+		for s := range ds.Samples() {
+			y = append(y, *s.GetTarget())
+		}
+		return y
+	}
+
+	if !slices.Equal(targets(train), []float32{5, 2}) || !slices.Equal(targets(test), []float32{4, 1}) {
+		t.Errorf("Wrong split : %v, %v", targets(train), targets(test))
+	}
+}
+
+func TestDataSet_SparseFeatures(t *testing.T) {
+	ds := load_csv(t, 1, "x,y\n1,10\n2,20\n3,30\n4,40\n")
+
+	m := sparse.NewCSR[float32](3)
+	m.AppendRow([]int{0}, []float32{1})
+	m.AppendRow(nil, nil)
+	m.AppendRow([]int{2}, []float32{3})
+	m.AppendRow([]int{1, 2}, []float32{2, 4})
+
+	if err := ds.AddSparseFeatures(&m); err != nil {
+		t.Errorf("DataSet.AddSparseFeatures should not error : %v", err)
+		t.FailNow()
+	}
+
+	if ds.FeatCount() != 4 {
+		t.Errorf("Wrong feature count : %d", ds.FeatCount())
+	}
+
+	view, _ := ds.Select([]int{3, 0})
+	expected := [][]float32{{4, 0, 2, 4}, {1, 1, 0, 0}}
+	for s := range view.Samples() {
+		x, err := s.GetSampleTest()
+		if err != nil || !slices.Equal(x, expected[0]) {
+			t.Errorf("Wrong sample : %v", x)
+		}
+		expected = expected[1:]
+	}
+
+	if err := view.AddSparseFeatures(&m); err == nil {
+		t.Error("Should not add features of another row count")
+	}
+
+	ds.Shuffle()
+	for s := range ds.Samples() {
+		x, _ := s.GetSampleTest()
+		if y := *s.GetTarget(); x[0]*10 != y {
+			t.Errorf("Shuffle should keep rows together : %v, %v", x, y)
+		}
+		if x[0] == 3 && x[3] != 3 {
+			t.Errorf("Shuffle should move the sparse features : %v", x)
+		}
+	}
+
+	if err := ds.DeriveColumn("z", func(dataset.RowView[float32]) dataset.DataCell { return &dataset.RealDataCell[float32]{} }); err != nil {
+		t.Errorf("DataSet.DeriveColumn should not error : %v", err)
+	}
+	for s := range ds.Samples() {
+		if x, _ := s.GetSampleTest(); x[0] == 4 && (x[3] != 2 || x[4] != 4) {
+			t.Errorf("Adding a column should keep the sparse features : %v", x)
+		}
+	}
+}
+
+func TestDataSet_Sparsify(t *testing.T) {
+	ds := load_csv(t, 3, "a,red,blue,y\n1.5,1,0,2\n2,0,1,3\n-1,0,0,4\n")
+
+	dense := [][]float32{}
+	for s := range ds.Samples() {
+		x, _ := s.GetSampleTest()
+		dense = append(dense, x)
+	}
+
+	if err := ds.Sparsify("a", "y"); err == nil {
+		t.Error("Should not sparsify the target")
+	}
+
+	if err := ds.Sparsify("red", "blue"); err != nil {
+		t.Errorf("DataSet.Sparsify should not error : %v", err)
+		t.FailNow()
+	}
+
+	if cols := ds.GetColumnNames(); !slices.Equal(cols, []string{"a", "y"}) || ds.FeatCount() != 3 || ds.SparseFeatCount() != 2 {
+		t.Errorf("Wrong columns after Sparsify : %v, %d features", cols, ds.FeatCount())
+	}
+
+	theta := []float32{1, 10, 100}
+	for s := range ds.Samples() {
+		x, _ := s.GetSampleTest()
+		if !slices.Equal(x, dense[s.GetRow()]) {
+			t.Errorf("Sparsify should keep the features : %v", x)
+		}
+
+		d, err := s.DotProduct(theta)
+		if expected := x[0] + 10*x[1] + 100*x[2]; err != nil || d != expected {
+			t.Errorf("Wrong dot product : %v. Expected : %v", d, expected)
+		}
+
+		if _, err := s.DotProduct(theta[:2]); err == nil {
+			t.Error("DotProduct should error on a vector shorter than the features")
+		}
+
+		if _, err := s.DotProduct(append(theta, 1)); err == nil {
+			t.Error("DotProduct should error on a vector longer than the features")
+		}
+
+		var nonzero []int
+		for i := range s.NonZeroFeats() {
+			nonzero = append(nonzero, i)
+		}
+		if s.GetRow() == 2 && !slices.Equal(nonzero, []int{0}) {
+			t.Errorf("Wrong non zero features : %v", nonzero)
+		}
+	}
+
+	for s := range ds.Samples() {
+		if *s.GetTarget() != float32(s.GetRow()+2) {
+			t.Errorf("Sparsify should keep the target : %v", *s.GetTarget())
+		}
+	}
+}
+
+func TestDataSet_WriteCsv(t *testing.T) {
+	ds := load_csv(t, 2, "a,name,y,drop\n1.25,x,1,0\n,y z,2,0\n3.5,\"q,r\",3,0\n4,w,4,0\n")
+	ds.DropColumn("drop")
+	view, _ := ds.Extract(0, 0.75)
+
+	var sb strings.Builder
+	if err := view.WriteCsv(&sb, dataset.CsvOptions{NA: "NA", FloatFormat: 'f', Precision: 1}); err != nil {
+		t.Errorf("DataSet.WriteCsv should not error : %v", err)
+		t.FailNow()
+	}
+
+	expected := "a,name,y\n1.2,x,1.0\nNA,y z,2.0\n3.5,\"q,r\",3.0\n"
+	if sb.String() != expected {
+		t.Errorf("Wrong CSV :\n%s", sb.String())
+	}
+
+	sb.Reset()
+	ds.WriteCsv(&sb, dataset.CsvOptions{Delim: ';'})
+	if !strings.HasPrefix(sb.String(), "a;name;y\n1.25;x;1\n;y z;2\n") {
+		t.Errorf("Wrong CSV with default options :\n%s", sb.String())
+	}
+
+	sparse_ds := load_csv(t, 1, "a,y\n1,2\n0,3\n")
+	sparse_ds.Sparsify("a")
+	if err := sparse_ds.WriteCsv(&sb, dataset.CsvOptions{}); err == nil {
+		t.Error("Should not silently drop sparse features")
+	}
+
+	if err := sparse_ds.WriteJSONL(&sb); err == nil {
+		t.Error("Should not silently drop sparse features")
+	}
+}
+
+func TestDataSet_WriteJSONL(t *testing.T) {
+	ds := dataset.NewDataSet[float32](1, dataset.WithTimeLayouts(time.DateOnly))
+	if err := ds.LoadCsvReader(strings.NewReader("when,y,note\n2024-01-02,1,\"say \"\"hi\"\"\"\n,2,\n"), ','); err != nil {
+		t.Fatalf("Failed to load CSV : %v", err)
+	}
+
+	var sb strings.Builder
+	if err := ds.WriteJSONL(&sb); err != nil {
+		t.Errorf("DataSet.WriteJSONL should not error : %v", err)
+		t.FailNow()
+	}
+
+	expected := `{"when":"2024-01-02T00:00:00Z","y":1,"note":"say \"hi\""}
+{"when":null,"y":2,"note":null}
+`
+	if sb.String() != expected {
+		t.Errorf("Wrong JSON lines :\n%s", sb.String())
+	}
+}
+
+func TestDataSet_Snapshot(t *testing.T) {
+	ds := dataset.NewDataSet[float64](0, dataset.WithTargets("y", "z"), dataset.WithWeights("w"), dataset.WithTimeLayouts(time.DateOnly))
+	str := strings.NewReader("when,name,y,z,w,unused\n2024-01-02,ab,0.1,1,2,0\n,,0.2,2,1,0\n2023-05-06,c,0.3,3,1,0\n")
+	if err := ds.LoadCsvReader(str, ','); err != nil {
+		t.Fatalf("Failed to load CSV : %v", err)
+	}
+	ds.DropColumn("unused")
+
+	m := sparse.NewCSR[float64](3)
+	m.AppendRow([]int{2}, []float64{0.5})
+	m.AppendRow(nil, nil)
+	m.AppendRow([]int{0, 1}, []float64{1, 2})
+	ds.AddSparseFeatures(&m)
+
+	view, _ := ds.Select([]int{2, 0})
+
+	var buf strings.Builder
+	if err := view.WriteSnapshot(&buf); err != nil {
+		t.Errorf("DataSet.WriteSnapshot should not error : %v", err)
+		t.FailNow()
+	}
+
+	loaded := dataset.NewDataSet[float64](0)
+	if err := loaded.LoadSnapshot(strings.NewReader(buf.String())); err != nil {
+		t.Errorf("DataSet.LoadSnapshot should not error : %v", err)
+		t.FailNow()
+	}
+
+	if cols := loaded.GetColumnNames(); !slices.Equal(cols, []string{"when", "name", "y", "z", "w"}) {
+		t.Errorf("Wrong columns : %v", cols)
+	}
+
+	if !slices.Equal(loaded.TargetNames(), []string{"y", "z"}) || !loaded.Weighted() || loaded.FeatCount() != 5 {
+		t.Errorf("Snapshot should keep targets, weights and features : %v", loaded.TargetNames())
+	}
+
+	var csv1, csv2 strings.Builder
+	view.WriteCsv(&csv1, dataset.CsvOptions{})
+	loaded.WriteCsv(&csv2, dataset.CsvOptions{})
+	if csv1.String() != csv2.String() {
+		t.Errorf("Snapshot should keep the cells :\n%s\n%s", csv1.String(), csv2.String())
+	}
+
+	for s := range loaded.Samples() {
+		x := s.GetSampleTestNoErr(-1)
+		expected := [][]float64{{-1, -1, 1, 2, 0}, {-1, -1, 0, 0, 0.5}}[s.GetRow()]
+		if !slices.Equal(x, expected) {
+			t.Errorf("Wrong features at row %d : %v", s.GetRow(), x)
+		}
+	}
+
+	single := dataset.NewDataSet[float32](0, dataset.WithTarget("z"))
+	if err := single.LoadSnapshot(strings.NewReader(buf.String())); err != nil || single.TargetCount() != 1 {
+		t.Errorf("Options should pick the targets of a snapshot : %v", err)
+		t.FailNow()
+	}
+
+	for s := range single.Samples() {
+		if s.GetRow() == 0 && *s.GetTarget() != 3 {
+			t.Errorf("Wrong target loaded as float32 : %v", *s.GetTarget())
+		}
+	}
+
+	empty := dataset.NewDataSet[float32](0)
+
+	if err := empty.LoadSnapshot(strings.NewReader("MLDS")); err == nil {
+		t.Error("Should reject a truncated snapshot")
+	}
+
+	for n := range len(buf.String()) {
+		if err := empty.LoadSnapshot(strings.NewReader(buf.String()[:n])); err == nil {
+			t.Errorf("Should reject a snapshot truncated to %d bytes", n)
+		}
+	}
+
+	// huge counts in the header of a tiny input
+	huge := [][]byte{
+		append([]byte("MLDS\x01\x08"), binary.AppendUvarint([]byte{1}, math.MaxInt32)...),                // name length
+		append([]byte("MLDS\x01\x08\x01\x01a\x01\x00\x00"), binary.AppendUvarint(nil, math.MaxInt32)...), // rows
+		append([]byte("MLDS\x01\x08"), binary.AppendUvarint(nil, math.MaxInt32)...),                      // columns
+		[]byte("MLDS\x01\x08\x02\x01a\x01b\x01\x00\x00\xff\xff\xff\xff\x07"),                             // rows times columns
+	}
+
+	for k, input := range huge {
+		if err := empty.LoadSnapshot(bytes.NewReader(input)); err == nil {
+			t.Errorf("Should reject corrupted snapshot %d", k)
+		}
+	}
+}
+
+func TestDataSet_LoadJSONL(t *testing.T) {
+	input := `{"id": 1, "user": {"name": "ann", "address": {"city": "Oslo"}}, "score": 2.5}
+{"id": 2, "user": {"name": null}, "tags": ["a", {"b": 1}], "score": "3"}
+
+{"id": 3, "ok": true, "score": 1e1}
+`
+	ds := dataset.NewDataSet[float32](0, dataset.WithTarget("score"))
+	if err := ds.LoadJSONLReader(strings.NewReader(input)); err != nil {
+		t.Errorf("DataSet.LoadJSONLReader should not error : %v", err)
+		t.FailNow()
+	}
+
+	if cols := ds.GetColumnNames(); !slices.Equal(cols, []string{"id", "user.name", "user.address.city", "score", "tags", "ok"}) {
+		t.Errorf("Wrong columns : %v", cols)
+	}
+
+	var sb strings.Builder
+	ds.WriteCsv(&sb, dataset.CsvOptions{NA: "NA"})
+	expected := `id,user.name,user.address.city,score,tags,ok
+1,ann,Oslo,2.5,NA,NA
+2,NA,NA,3,"[""a"",{""b"":1}]",NA
+3,NA,NA,10,NA,true
+`
+	if sb.String() != expected {
+		t.Errorf("Wrong cells :\n%s", sb.String())
+	}
+
+	if err := ds.LoadJSONLReader(strings.NewReader("[1, 2]")); err == nil {
+		t.Error("Should reject records which are not objects")
+	}
+
+	bom := dataset.NewDataSet[float32](0)
+	if err := bom.LoadJSONLReader(strings.NewReader("\xef\xbb\xbf{\"x\": 1}\n")); err != nil || bom.Size() != 1 {
+		t.Errorf("DataSet.LoadJSONLReader should skip a byte order mark : %v", err)
+	}
+}
+
+func TestDataSet_LoadJSON(t *testing.T) {
+	ds := dataset.NewDataSet[float64](1)
+	if err := ds.LoadJSONReader(strings.NewReader(`[{"x": 1, "y": 2}, {"y": 6, "x": 3}]`)); err != nil {
+		t.Errorf("DataSet.LoadJSONReader should not error : %v", err)
+		t.FailNow()
+	}
+
+	for s := range ds.Samples() {
+		if x := s.GetFeat(0); x == nil || *s.GetTarget() != 2**x {
+			t.Errorf("Wrong row %d", s.GetRow())
+		}
+	}
+
+	empty := dataset.NewDataSet[float64](0)
+	if err := empty.LoadJSONReader(strings.NewReader(`{"x": 1}`)); err == nil {
+		t.Error("Should reject input which is not an array")
+	}
+
+	bom := dataset.NewDataSet[float64](0)
+	if err := bom.LoadJSONReader(strings.NewReader("\xef\xbb\xbf[{\"x\": 1}]")); err != nil || bom.Size() != 1 {
+		t.Errorf("DataSet.LoadJSONReader should skip a byte order mark : %v", err)
+	}
+}
+
+func TestDataSet_StreamJSONL(t *testing.T) {
+	input := `{"x": 1, "y": 1}
+{"x": 2, "y": 2}
+{"x": 3, "z": "new", "y": 3}
+`
+	ds := dataset.NewDataSet[float32](0, dataset.WithTarget("y"))
+
+	var sizes []uint32
+	var columns [][]string
+	err := ds.StreamJSONL(strings.NewReader(input), 2, func(chunk *dataset.DataSet[float32]) error {
+		sizes = append(sizes, chunk.Size())
+		columns = append(columns, chunk.GetColumnNames())
+		chunk.DropColumn("x")
+		return nil
+	})
+
+	if err != nil {
+		t.Errorf("DataSet.StreamJSONL should not error : %v", err)
+		t.FailNow()
+	}
+
+	if !slices.Equal(sizes, []uint32{2, 1}) || !slices.Equal(columns[1], []string{"x", "y", "z"}) {
+		t.Errorf("Wrong chunks : %v, %v", sizes, columns)
+	}
+	rows := 0
+	err = ds.StreamJSONL(strings.NewReader("\xef\xbb\xbf"+input), 2, func(chunk *dataset.DataSet[float32]) error {
+		rows += int(chunk.Size())
+		return nil
+	})
+
+	if err != nil || rows != 3 {
+		t.Errorf("DataSet.StreamJSONL should skip a byte order mark : %v", err)
+	}
+}
+
+func TestSniffCsv(t *testing.T) {
+	tests := []struct {
+		name     string
+		sample   string
+		expected dataset.CsvDialect
+	}{
+		{"Comma", "a,b,c\n1,2,3\n", dataset.CsvDialect{Delim: ','}},
+		{"Semicolon with quoted commas", "name;price\n\"1,5 kg\";2,5\n\"x, y\";3,75\n", dataset.CsvDialect{Delim: ';', Quoted: true}},
+		{"Tab", "a\tb\n1\t2,5\n3\t4,5\n", dataset.CsvDialect{Delim: '\t'}},
+		{"Pipe", "a|b|c\n1|2|3\n4|5|6\n", dataset.CsvDialect{Delim: '|'}},
+		{"Stray quotes", "size,item\n27,24\" screen\n", dataset.CsvDialect{Delim: ',', LazyQuotes: true}},
+		{"Single column", "a\n1\n2\n", dataset.CsvDialect{Delim: ','}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if d := dataset.SniffCsv([]byte(tt.sample), false); d != tt.expected {
+				t.Errorf("Wrong dialect : %+v. Expected : %+v", d, tt.expected)
+			}
+		})
+	}
+
+	// the truncated last line would be a tie breaker for ','
+	if d := dataset.SniffCsv([]byte("a;b\n1;2\n3,4,5,6,7"), true); d.Delim != ';' {
+		t.Errorf("Truncated line should be ignored : %q", d.Delim)
+	}
+}
+
+func TestDataSet_LoadAuto(t *testing.T) {
+	dir := t.TempDir()
+	csv := "\xef\xbb\xbfa;y\n1;2\n3;4\n"
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte(csv))
+	w.Close()
+
+	bz := []byte{
+		0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0xef, 0x2b, 0x6e, 0x79, 0x00, 0x00, 0x05,
+		0x49, 0x80, 0x00, 0x10, 0x3c, 0x08, 0x20, 0x00, 0x00, 0x20, 0x20, 0x00, 0x22, 0x1a, 0x60, 0x84, 0x30,
+		0x22, 0x96, 0x60, 0x0e, 0x54, 0xbc, 0x5d, 0xc9, 0x14, 0xe1, 0x42, 0x43, 0xbc, 0xad, 0xb9, 0xe4,
+	}
+
+	var jsonl bytes.Buffer
+	w = gzip.NewWriter(&jsonl)
+	w.Write([]byte("{\"a\": 1, \"y\": 2}\n{\"a\": 3, \"y\": 4}\n"))
+	w.Close()
+
+	files := map[string][]byte{
+		"plain.csv":        []byte(csv),
+		"export.csv.gz":    gz.Bytes(),
+		"export.bz2":       bz,
+		"records.jsonl.gz": jsonl.Bytes(),
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatalf("Failed to write %s : %v", name, err)
+		}
+
+		ds := dataset.NewDataSet[float32](0, dataset.WithTarget("y"))
+		if err := ds.LoadAuto(path); err != nil {
+			t.Errorf("DataSet.LoadAuto(%s) should not error : %v", name, err)
+			continue
+		}
+
+		if cols := ds.GetColumnNames(); !slices.Equal(cols, []string{"a", "y"}) || ds.Size() != 2 {
+			t.Errorf("Wrong dataset loaded from %s : %q, %d rows", name, cols, ds.Size())
+		}
+	}
+
+	ds := dataset.NewDataSet[float32](0)
+	if err := ds.LoadCsv(filepath.Join(dir, "export.csv.gz"), ';'); err != nil || ds.Size() != 2 {
+		t.Errorf("DataSet.LoadCsv should decompress its input : %v", err)
+	}
+
+	// only the first byte order mark is skipped, the second one belongs to the first header
+	twice := filepath.Join(dir, "twice.csv")
+	if err := os.WriteFile(twice, []byte("\xef\xbb\xbf"+csv), 0o644); err != nil {
+		t.Fatalf("Failed to write %s : %v", twice, err)
+	}
+
+	bom := dataset.NewDataSet[float32](0)
+	if err := bom.LoadCsv(twice, ';'); err != nil || bom.GetColumnNames()[0] != "\ufeffa" {
+		t.Errorf("DataSet.LoadCsv should skip a single byte order mark : %q, %v", bom.GetColumnNames(), err)
+	}
+
+	var snapshot bytes.Buffer
+	w = gzip.NewWriter(&snapshot)
+	ds.WriteSnapshot(w)
+	w.Close()
+
+	loaded := dataset.NewDataSet[float32](0)
+	if err := loaded.LoadAutoReader(&snapshot); err != nil || loaded.Size() != 2 {
+		t.Errorf("DataSet.LoadAutoReader should recognize snapshots : %v", err)
+	}
+
+	// "BZh" without the block size digit is plain text
+	text := dataset.NewDataSet[float32](0)
+	if err := text.LoadAutoReader(strings.NewReader("BZh,y\n1,2\n")); err != nil || !slices.Equal(text.GetColumnNames(), []string{"BZh", "y"}) {
+		t.Errorf("DataSet.LoadAutoReader should not take text starting with BZh for bzip2 : %v", err)
+	}
+}
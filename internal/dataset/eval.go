@@ -0,0 +1,110 @@
+package dataset
+
+import (
+	"fmt"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset/expr"
+)
+
+// Implements expr.Env. Empty cells are null and times are seconds since the unix epoch
+func (r RowView[T]) Lookup(name string) (expr.Value, error) {
+	j, found := r.columns[name]
+	if !found {
+		return expr.Null(), fmt.Errorf("unknown column %q", name)
+	}
+
+	switch c := r.sample.At(j).(type) {
+	case *RealDataCell[T]:
+		return expr.Num(float64(c.Value)), nil
+	case *StrDataCell:
+		return expr.Str(c.Value), nil
+	case *TimeDataCell:
+		return expr.Num(float64(c.Value.UnixNano()) / 1e9), nil
+	}
+	return expr.Null(), nil
+}
+
+func (ds *DataSet[T]) check_columns(e expr.Node) error {
+	for _, name := range expr.Columns(e) {
+		if ds.ColumnIndex(name) == -1 {
+			return fmt.Errorf("column %q not found", name)
+		}
+	}
+	return nil
+}
+
+/*
+Append a column from an assignment like "bmi = weight / height^2".
+Numbers and booleans give real cells, strings give string cells and null gives an empty cell
+*/
+func (ds *DataSet[T]) Eval(assignment string) error {
+	name, e, err := expr.ParseAssignment(assignment)
+	if err != nil {
+		return fmt.Errorf("DataSet.Eval : %w", err)
+	}
+
+	if err := ds.check_columns(e); err != nil {
+		return fmt.Errorf("DataSet.Eval : %w", err)
+	}
+
+	// every row is evaluated before the column is added, so that a failure leaves the dataset untouched
+	columns := ds.column_lookup()
+	values := make([]DataCell, 0, ds.Size())
+
+	for s := range ds.Samples() {
+		v, err := e.Eval(RowView[T]{s, columns})
+		if err != nil {
+			return fmt.Errorf("DataSet.Eval : row %d : %w", s.GetRow(), err)
+		}
+
+		switch v.Kind {
+		case expr.KindNumber:
+			values = append(values, &RealDataCell[T]{T(v.Num)})
+		case expr.KindBool:
+			if v.Bool {
+				values = append(values, &RealDataCell[T]{1})
+			} else {
+				values = append(values, &RealDataCell[T]{0})
+			}
+		case expr.KindString:
+			values = append(values, &StrDataCell{v.Str})
+		default:
+			values = append(values, nil)
+		}
+	}
+
+	if err := ds.AddColumn(name, values); err != nil {
+		return fmt.Errorf("DataSet.Eval : %w", err)
+	}
+	return nil
+}
+
+// View of the rows for which the condition holds. Null results reject the row
+func (ds *DataSet[T]) Where(condition string) (*DataSet[T], error) {
+	e, err := expr.Parse(condition)
+	if err != nil {
+		return nil, fmt.Errorf("DataSet.Where : %w", err)
+	}
+
+	if err := ds.check_columns(e); err != nil {
+		return nil, fmt.Errorf("DataSet.Where : %w", err)
+	}
+
+	columns := ds.column_lookup()
+	rows := []int{}
+	i := 0
+
+	for s := range ds.Samples() {
+		v, err := e.Eval(RowView[T]{s, columns})
+		if err != nil {
+			return nil, fmt.Errorf("DataSet.Where : row %d : %w", i, err)
+		}
+
+		if v.Truthy() {
+			rows = append(rows, i)
+		}
+		i++
+	}
+
+	return ds.Select(rows)
+}
@@ -0,0 +1,397 @@
+/*
+Small expression language for derived columns and row filters.
+
+	bmi = weight / height^2
+	age >= 18 && country == "FR"
+	if(isnull(`Sleep Hours`), 0, clip(log(`Sleep Hours`), 0, 3))
+
+Operators are + - * / % ^, comparisons, && || ! and string equality.
+Column names holding spaces or symbols are backquoted. Any operation on a null value yields null,
+except == and != which compare nullness, and && || which treat null as false
+*/
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+type Kind int
+
+const (
+	KindNull Kind = iota
+	KindNumber
+	KindString
+	KindBool
+)
+
+type Value struct {
+	Kind Kind
+	Num  float64
+	Str  string
+	Bool bool
+}
+
+func Null() Value {
+	return Value{}
+}
+
+func Num(v float64) Value {
+	return Value{Kind: KindNumber, Num: v}
+}
+
+func Str(s string) Value {
+	return Value{Kind: KindString, Str: s}
+}
+
+func Bool(b bool) Value {
+	return Value{Kind: KindBool, Bool: b}
+}
+
+func (v Value) IsNull() bool {
+	return v.Kind == KindNull
+}
+
+// Non zero numbers, non empty strings and true are truthy. Null is not
+func (v Value) Truthy() bool {
+	switch v.Kind {
+	case KindNumber:
+		return v.Num != 0 && !math.IsNaN(v.Num)
+	case KindString:
+		return v.Str != ""
+	case KindBool:
+		return v.Bool
+	}
+	return false
+}
+
+func (v Value) String() string {
+	switch v.Kind {
+	case KindNumber:
+		return strconv.FormatFloat(v.Num, 'g', -1, 64)
+	case KindString:
+		return strconv.Quote(v.Str)
+	case KindBool:
+		return strconv.FormatBool(v.Bool)
+	}
+	return "null"
+}
+
+// Booleans count as 0 and 1 in arithmetic
+func (v Value) number() (float64, bool) {
+	switch v.Kind {
+	case KindNumber:
+		return v.Num, true
+	case KindBool:
+		if v.Bool {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// Resolves column values of the row being evaluated
+type Env interface {
+	Lookup(name string) (Value, error)
+}
+
+type Node interface {
+	Eval(env Env) (Value, error)
+	columns(seen map[string]bool, out []string) []string
+}
+
+// Column names referenced by the expression, in order of appearance
+func Columns(n Node) []string {
+	return n.columns(map[string]bool{}, nil)
+}
+
+type literal_node struct {
+	value Value
+}
+
+func (n *literal_node) Eval(Env) (Value, error) {
+	return n.value, nil
+}
+
+func (n *literal_node) columns(_ map[string]bool, out []string) []string {
+	return out
+}
+
+type column_node struct {
+	name string
+}
+
+func (n *column_node) Eval(env Env) (Value, error) {
+	return env.Lookup(n.name)
+}
+
+func (n *column_node) columns(seen map[string]bool, out []string) []string {
+	if !seen[n.name] {
+		seen[n.name] = true
+		out = append(out, n.name)
+	}
+	return out
+}
+
+type unary_node struct {
+	op      string
+	operand Node
+}
+
+func (n *unary_node) Eval(env Env) (Value, error) {
+	v, err := n.operand.Eval(env)
+	if err != nil || v.IsNull() {
+		return v, err
+	}
+
+	if n.op == "!" {
+		return Bool(!v.Truthy()), nil
+	}
+
+	x, ok := v.number()
+	if !ok {
+		return Null(), fmt.Errorf("expr : cannot negate %v", v)
+	}
+	return Num(-x), nil
+}
+
+func (n *unary_node) columns(seen map[string]bool, out []string) []string {
+	return n.operand.columns(seen, out)
+}
+
+type binary_node struct {
+	op          string
+	left, right Node
+}
+
+func (n *binary_node) Eval(env Env) (Value, error) {
+	l, err := n.left.Eval(env)
+	if err != nil {
+		return Null(), err
+	}
+
+	// short circuit
+	switch n.op {
+	case "&&":
+		if !l.Truthy() {
+			return Bool(false), nil
+		}
+	case "||":
+		if l.Truthy() {
+			return Bool(true), nil
+		}
+	}
+
+	r, err := n.right.Eval(env)
+	if err != nil {
+		return Null(), err
+	}
+
+	switch n.op {
+	case "&&", "||":
+		return Bool(r.Truthy()), nil
+	case "==":
+		return Bool(equal(l, r)), nil
+	case "!=":
+		return Bool(!equal(l, r)), nil
+	}
+
+	if l.IsNull() || r.IsNull() {
+		return Null(), nil
+	}
+
+	if l.Kind == KindString && r.Kind == KindString {
+		switch n.op {
+		case "<":
+			return Bool(l.Str < r.Str), nil
+		case "<=":
+			return Bool(l.Str <= r.Str), nil
+		case ">":
+			return Bool(l.Str > r.Str), nil
+		case ">=":
+			return Bool(l.Str >= r.Str), nil
+		case "+":
+			return Str(l.Str + r.Str), nil
+		}
+	}
+
+	x, ok_x := l.number()
+	y, ok_y := r.number()
+	if !ok_x || !ok_y {
+		return Null(), fmt.Errorf("expr : invalid operands %v %s %v", l, n.op, r)
+	}
+
+	switch n.op {
+	case "<":
+		return Bool(x < y), nil
+	case "<=":
+		return Bool(x <= y), nil
+	case ">":
+		return Bool(x > y), nil
+	case ">=":
+		return Bool(x >= y), nil
+	case "+":
+		return Num(x + y), nil
+	case "-":
+		return Num(x - y), nil
+	case "*":
+		return Num(x * y), nil
+	case "/":
+		return Num(x / y), nil
+	case "%":
+		return Num(math.Mod(x, y)), nil
+	case "^":
+		return Num(math.Pow(x, y)), nil
+	}
+
+	return Null(), fmt.Errorf("expr : unknown operator %q", n.op)
+}
+
+func (n *binary_node) columns(seen map[string]bool, out []string) []string {
+	return n.right.columns(seen, n.left.columns(seen, out))
+}
+
+func equal(l, r Value) bool {
+	if l.IsNull() || r.IsNull() {
+		return l.IsNull() && r.IsNull()
+	}
+
+	if l.Kind == KindString || r.Kind == KindString {
+		return l.Kind == r.Kind && l.Str == r.Str
+	}
+
+	x, _ := l.number()
+	y, _ := r.number()
+	return x == y
+}
+
+type function struct {
+	arity int // -1 for variadic
+	eval  func(args []Value) (Value, error)
+}
+
+func numeric(name string, f func(float64) float64) function {
+	return function{1, func(args []Value) (Value, error) {
+		if args[0].IsNull() {
+			return Null(), nil
+		}
+
+		x, ok := args[0].number()
+		if !ok {
+			return Null(), fmt.Errorf("expr : %s expects a number, got %v", name, args[0])
+		}
+		return Num(f(x)), nil
+	}}
+}
+
+var functions = map[string]function{
+	"log":  numeric("log", math.Log),
+	"exp":  numeric("exp", math.Exp),
+	"abs":  numeric("abs", math.Abs),
+	"sqrt": numeric("sqrt", math.Sqrt),
+	"isnull": {1, func(args []Value) (Value, error) {
+		return Bool(args[0].IsNull()), nil
+	}},
+	"clip": {3, func(args []Value) (Value, error) {
+		for _, a := range args {
+			if a.IsNull() {
+				return Null(), nil
+			}
+		}
+
+		x, ok_x := args[0].number()
+		lo, ok_lo := args[1].number()
+		hi, ok_hi := args[2].number()
+		if !ok_x || !ok_lo || !ok_hi {
+			return Null(), fmt.Errorf("expr : clip expects numbers")
+		}
+		return Num(min(max(x, lo), hi)), nil
+	}},
+	"min": {-1, extremum("min", func(a, b float64) bool { return a < b })},
+	"max": {-1, extremum("max", func(a, b float64) bool { return a > b })},
+}
+
+func extremum(name string, better func(a, b float64) bool) func(args []Value) (Value, error) {
+	return func(args []Value) (Value, error) {
+		if len(args) == 0 {
+			return Null(), fmt.Errorf("expr : %s expects at least one argument", name)
+		}
+
+		var best float64
+		for i, a := range args {
+			if a.IsNull() {
+				return Null(), nil
+			}
+
+			x, ok := a.number()
+			if !ok {
+				return Null(), fmt.Errorf("expr : %s expects numbers, got %v", name, a)
+			}
+			if i == 0 || better(x, best) {
+				best = x
+			}
+		}
+		return Num(best), nil
+	}
+}
+
+type call_node struct {
+	name string
+	fn   function
+	args []Node
+}
+
+// if(cond, a, b) is lazy and handled apart from the other functions
+func new_call(name string, args []Node, at int) (Node, error) {
+	if name == "if" {
+		if len(args) != 3 {
+			return nil, fmt.Errorf("expr : if expects 3 arguments at %d", at)
+		}
+		return &call_node{name: name, args: args}, nil
+	}
+
+	fn, ok := functions[name]
+	if !ok {
+		return nil, fmt.Errorf("expr : unknown function %q at %d", name, at)
+	}
+
+	if fn.arity >= 0 && len(args) != fn.arity {
+		return nil, fmt.Errorf("expr : %s expects %d arguments at %d", name, fn.arity, at)
+	}
+
+	return &call_node{name, fn, args}, nil
+}
+
+func (n *call_node) Eval(env Env) (Value, error) {
+	if n.name == "if" {
+		cond, err := n.args[0].Eval(env)
+		if err != nil {
+			return Null(), err
+		}
+
+		if cond.Truthy() {
+			return n.args[1].Eval(env)
+		}
+		return n.args[2].Eval(env)
+	}
+
+	values := make([]Value, len(n.args))
+	for i, a := range n.args {
+		v, err := a.Eval(env)
+		if err != nil {
+			return Null(), err
+		}
+		values[i] = v
+	}
+
+	return n.fn.eval(values)
+}
+
+func (n *call_node) columns(seen map[string]bool, out []string) []string {
+	for _, a := range n.args {
+		out = a.columns(seen, out)
+	}
+	return out
+}
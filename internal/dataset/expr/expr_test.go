@@ -0,0 +1,83 @@
+package expr_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset/expr"
+)
+
+type env map[string]expr.Value
+
+func (e env) Lookup(name string) (expr.Value, error) {
+	if v, ok := e[name]; ok {
+		return v, nil
+	}
+	return expr.Null(), errors.New("unknown column " + name)
+}
+
+func TestEval(t *testing.T) {
+	row := env{
+		"weight":      expr.Num(80),
+		"height":      expr.Num(2),
+		"country":     expr.Str("FR"),
+		"Sleep Hours": expr.Null(),
+	}
+
+	cases := []struct {
+		src  string
+		want expr.Value
+	}{
+		{"weight / height^2", expr.Num(20)},
+		{"-height^2", expr.Num(-4)},
+		{"2^3^2", expr.Num(512)},
+		{"1 + 2 * 3 % 4", expr.Num(3)},
+		{"weight > 50 && country == \"FR\"", expr.Bool(true)},
+		{"country != 'FR' || height < 1", expr.Bool(false)},
+		{"`Sleep Hours` + 1", expr.Null()},
+		{"`Sleep Hours` == null", expr.Bool(true)},
+		{"isnull(`Sleep Hours`)", expr.Bool(true)},
+		{"if(isnull(`Sleep Hours`), 7, `Sleep Hours`)", expr.Num(7)},
+		{"clip(weight, 0, 50)", expr.Num(50)},
+		{"abs(-3) + exp(0) + log(1)", expr.Num(4)},
+		{"max(1, height, 0.5)", expr.Num(2)},
+		{"1.5e1", expr.Num(15)},
+	}
+
+	for _, c := range cases {
+		e, err := expr.Parse(c.src)
+		if err != nil {
+			t.Errorf("%s : %v", c.src, err)
+			continue
+		}
+
+		got, err := e.Eval(row)
+		if err != nil {
+			t.Errorf("%s : %v", c.src, err)
+			continue
+		}
+
+		if got.Kind != c.want.Kind || got.Str != c.want.Str || got.Bool != c.want.Bool || math.Abs(got.Num-c.want.Num) > 1e-12 {
+			t.Errorf("%s = %v, expected %v", c.src, got, c.want)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, src := range []string{"1 +", "(1 + 2", "foo(1)", "clip(1, 2)", "a $ b", "\"open", "a = 1"} {
+		if _, err := expr.Parse(src); err == nil {
+			t.Errorf("%s : should not parse", src)
+		}
+	}
+
+	name, e, err := expr.ParseAssignment("`Body mass` = weight / height^2")
+	if err != nil || name != "Body mass" {
+		t.Errorf("Failed to parse assignment : %q %v", name, err)
+		t.FailNow()
+	}
+
+	if cols := expr.Columns(e); len(cols) != 2 || cols[0] != "weight" || cols[1] != "height" {
+		t.Errorf("Wrong referenced columns : %v", cols)
+	}
+}
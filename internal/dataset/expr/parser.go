@@ -0,0 +1,319 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type token_kind int
+
+const (
+	tok_eof token_kind = iota
+	tok_number
+	tok_string
+	tok_ident
+	tok_op
+)
+
+type token struct {
+	kind   token_kind
+	text   string
+	num    float64
+	start  int
+	quoted bool // backquoted identifier
+}
+
+func is_ident_rune(r rune, first bool) bool {
+	if r == '_' || unicode.IsLetter(r) {
+		return true
+	}
+	return !first && (unicode.IsDigit(r) || r == '.')
+}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == 'e' || runes[i] == 'E' ||
+				((runes[i] == '-' || runes[i] == '+') && (runes[i-1] == 'e' || runes[i-1] == 'E'))) {
+				i++
+			}
+
+			num, err := strconv.ParseFloat(string(runes[start:i]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("expr : invalid number %q at %d", string(runes[start:i]), start)
+			}
+			tokens = append(tokens, token{kind: tok_number, text: string(runes[start:i]), num: num, start: start})
+
+		case r == '"' || r == '\'' || r == '`':
+			// backquotes delimit column names holding spaces or symbols
+			start := i
+			var sb strings.Builder
+			for i++; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+
+			if i >= len(runes) {
+				return nil, fmt.Errorf("expr : unterminated quote at %d", start)
+			}
+			i++
+
+			kind := tok_string
+			if r == '`' {
+				kind = tok_ident
+			}
+			tokens = append(tokens, token{kind: kind, text: sb.String(), start: start, quoted: r == '`'})
+
+		case is_ident_rune(r, true):
+			start := i
+			for i < len(runes) && is_ident_rune(runes[i], false) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tok_ident, text: string(runes[start:i]), start: start})
+
+		default:
+			start := i
+			op := string(r)
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "==", "!=", "<=", ">=", "&&", "||":
+					op = two
+				}
+			}
+
+			if !strings.Contains("+-*/%^()<>=!,", string(r)) && len(op) == 1 {
+				return nil, fmt.Errorf("expr : unexpected character %q at %d", r, start)
+			}
+
+			i += len([]rune(op))
+			tokens = append(tokens, token{kind: tok_op, text: op, start: start})
+		}
+	}
+
+	return append(tokens, token{kind: tok_eof, start: len(runes)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tok_eof {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) is_op(op string) bool {
+	t := p.peek()
+	return t.kind == tok_op && t.text == op
+}
+
+func (p *parser) expect(op string) error {
+	if !p.is_op(op) {
+		return fmt.Errorf("expr : expected %q at %d", op, p.peek().start)
+	}
+	p.next()
+	return nil
+}
+
+// binary operators from the loosest to the tightest
+var precedences = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) binary(level int) (Node, error) {
+	if level == len(precedences) {
+		return p.unary()
+	}
+
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		if t.kind != tok_op || !contains(precedences[level], t.text) {
+			return left, nil
+		}
+		p.next()
+
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binary_node{t.text, left, right}
+	}
+}
+
+func (p *parser) unary() (Node, error) {
+	if p.is_op("-") || p.is_op("!") {
+		op := p.next().text
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unary_node{op, operand}, nil
+	}
+	return p.power()
+}
+
+// ^ binds tighter than unary minus and is right associative : -x^2^3 = -(x^(2^3))
+func (p *parser) power() (Node, error) {
+	base, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.is_op("^") {
+		p.next()
+		exp, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &binary_node{"^", base, exp}, nil
+	}
+
+	return base, nil
+}
+
+func (p *parser) primary() (Node, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tok_number:
+		return &literal_node{Num(t.num)}, nil
+
+	case tok_string:
+		return &literal_node{Str(t.text)}, nil
+
+	case tok_ident:
+		// backquoted names are always columns
+		if t.quoted {
+			return &column_node{t.text}, nil
+		}
+
+		if !p.is_op("(") {
+			switch t.text {
+			case "true":
+				return &literal_node{Bool(true)}, nil
+			case "false":
+				return &literal_node{Bool(false)}, nil
+			case "null":
+				return &literal_node{Null()}, nil
+			}
+			return &column_node{t.text}, nil
+		}
+
+		p.next()
+		var args []Node
+		for !p.is_op(")") {
+			if len(args) > 0 {
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+
+			arg, err := p.binary(0)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+		p.next()
+
+		return new_call(t.text, args, t.start)
+
+	case tok_op:
+		if t.text == "(" {
+			e, err := p.binary(0)
+			if err != nil {
+				return nil, err
+			}
+			return e, p.expect(")")
+		}
+	}
+
+	if t.kind == tok_eof {
+		return nil, fmt.Errorf("expr : unexpected end of expression")
+	}
+	return nil, fmt.Errorf("expr : unexpected %q at %d", t.text, t.start)
+}
+
+func contains(ops []string, op string) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse an expression
+func Parse(src string) (Node, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{tokens: tokens}
+	e, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tok_eof {
+		return nil, fmt.Errorf("expr : unexpected %q at %d", t.text, t.start)
+	}
+
+	return e, nil
+}
+
+// Parse an assignment "name = expression". The name may be backquoted
+func ParseAssignment(src string) (string, Node, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(tokens) < 4 || tokens[0].kind != tok_ident || tokens[1].kind != tok_op || tokens[1].text != "=" {
+		return "", nil, fmt.Errorf("expr : expected \"name = expression\"")
+	}
+
+	p := parser{tokens: tokens, pos: 2}
+	e, err := p.binary(0)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if t := p.peek(); t.kind != tok_eof {
+		return "", nil, fmt.Errorf("expr : unexpected %q at %d", t.text, t.start)
+	}
+
+	return tokens[0].text, e, nil
+}
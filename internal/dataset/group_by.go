@@ -0,0 +1,261 @@
+package dataset
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bleak-and-bare/machine_learning/internal/maths"
+	"golang.org/x/exp/constraints"
+)
+
+// Numeric aggregations skip empty and string cells
+type AggOp int
+
+const (
+	AggCount AggOp = iota // non empty cells, or rows when Column is ""
+	AggSum
+	AggMean
+	AggVariance // population variance, see maths.WelfordVar
+	AggMin
+	AggMax
+	AggMedian
+	AggFirst  // first non empty cell
+	AggLast   // last non empty cell
+	AggCustom // calls Aggregation.Custom
+)
+
+var agg_names = []string{"count", "sum", "mean", "var", "min", "max", "median", "first", "last", "custom"}
+
+type Aggregation struct {
+	Column string
+	Op     AggOp
+	Name   string // name of the output column. Defaults to "<Column>_<op>", or "count" for a row count
+
+	// Reduces the cells of one group, empty cells included. It is called from several goroutines
+	Custom func(cells []DataCell) DataCell
+}
+
+func (a Aggregation) name() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	if a.Column == "" {
+		return agg_names[a.Op]
+	}
+	return a.Column + "_" + agg_names[a.Op]
+}
+
+// Rows of a dataset split by the values of some columns
+type Grouped[T constraints.Float] struct {
+	ds     *DataSet[T]
+	keys   []int
+	groups [][]int // rows of each group, in order of first appearance
+}
+
+// Groups the rows by the values of columns, compared by value. Empty cells form their own group
+func (ds *DataSet[T]) GroupBy(columns ...string) (*Grouped[T], error) {
+	if len(columns) == 0 {
+		return nil, errors.New("DataSet.GroupBy : no column provided")
+	}
+
+	keys, err := ds.column_indices("GroupBy", columns)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Grouped[T]{ds: ds, keys: keys}
+	lookup := make(map[string]int)
+	var sb strings.Builder
+
+	for s := range ds.Samples() {
+		sb.Reset()
+		for _, j := range keys {
+			key_of[T](s.At(j)).write(&sb)
+		}
+
+		k, found := lookup[sb.String()]
+		if !found {
+			k = len(g.groups)
+			lookup[sb.String()] = k
+			g.groups = append(g.groups, nil)
+		}
+		g.groups[k] = append(g.groups[k], s.row)
+	}
+
+	return g, nil
+}
+
+// Number of groups
+func (g *Grouped[T]) Len() int {
+	return len(g.groups)
+}
+
+/*
+One row per group holding the group columns followed by the aggregations.
+The first aggregation is the target of the returned dataset. Groups are reduced in parallel.
+A weighted dataset gives a last column, named after its weight column, holding the total weight of each group
+*/
+func (g *Grouped[T]) Agg(aggs ...Aggregation) (*DataSet[T], error) {
+	if len(aggs) == 0 {
+		return nil, errors.New("Grouped.Agg : no aggregation provided")
+	}
+
+	var names []string
+	inputs := make([]int, len(aggs))
+
+	for _, j := range g.keys {
+		names = append(names, g.ds.headers[j].name)
+	}
+
+	for k, a := range aggs {
+		if a.Op < AggCount || a.Op > AggCustom || (a.Op == AggCustom && a.Custom == nil) {
+			return nil, fmt.Errorf("Grouped.Agg : invalid aggregation on %q", a.Column)
+		}
+
+		inputs[k] = -1
+		if a.Column != "" {
+			if inputs[k] = g.ds.ColumnIndex(a.Column); inputs[k] == -1 {
+				return nil, fmt.Errorf("Grouped.Agg : column %q not found", a.Column)
+			}
+		} else if a.Op != AggCount {
+			return nil, errors.New("Grouped.Agg : only a count can omit its column")
+		}
+
+		if slices.Contains(names, a.name()) {
+			return nil, fmt.Errorf("Grouped.Agg : column %q produced twice", a.name())
+		}
+		names = append(names, a.name())
+	}
+
+	weight := g.ds.weight_name()
+	if weight != "" {
+		if slices.Contains(names, weight) {
+			return nil, fmt.Errorf("Grouped.Agg : column %q produced twice", weight)
+		}
+		names = append(names, weight)
+	}
+
+	width := len(names)
+	datas := make([]DataCell, len(g.groups)*width)
+	jobs := make(chan int, len(g.groups))
+
+	var wg sync.WaitGroup
+	for range min(runtime.NumCPU(), len(g.groups)) {
+		wg.Go(func() {
+			for k := range jobs {
+				row := datas[k*width : (k+1)*width]
+				first := g.groups[k][0]
+
+				for c, j := range g.keys {
+					row[c] = g.ds.at(first, j)
+				}
+
+				for c, a := range aggs {
+					row[len(g.keys)+c] = g.reduce(a, inputs[c], g.groups[k])
+				}
+
+				if weight != "" {
+					row[width-1] = g.total_weight(g.groups[k])
+				}
+			}
+		})
+	}
+
+	for k := range g.groups {
+		jobs <- k
+	}
+	close(jobs)
+	wg.Wait()
+
+	res, err := from_cells[T](names, len(g.keys), weight, datas)
+	if err != nil {
+		return nil, fmt.Errorf("Grouped.Agg : %w", err)
+	}
+	return res, nil
+}
+
+func (g *Grouped[T]) total_weight(rows []int) DataCell {
+	var total T
+	for _, r := range rows {
+		s := DataSample[T]{g.ds, r}
+		total += s.GetWeight()
+	}
+	return &RealDataCell[T]{total}
+}
+
+func (g *Grouped[T]) reduce(a Aggregation, j int, rows []int) DataCell {
+	if j == -1 {
+		return &RealDataCell[T]{T(len(rows))}
+	}
+
+	cells := make([]DataCell, len(rows))
+	for i, r := range rows {
+		cells[i] = g.ds.at(r, j)
+	}
+
+	switch a.Op {
+	case AggCustom:
+		return a.Custom(cells)
+	case AggFirst, AggLast:
+		if a.Op == AggLast {
+			slices.Reverse(cells)
+		}
+		if i := slices.IndexFunc(cells, func(c DataCell) bool { return c != nil }); i != -1 {
+			return cells[i]
+		}
+		return nil
+	}
+
+	var values []T
+	for _, c := range cells {
+		if v, ok := c.(*RealDataCell[T]); ok {
+			values = append(values, v.Value)
+		}
+	}
+
+	if a.Op == AggCount {
+		count := 0
+		for _, c := range cells {
+			if c != nil {
+				count++
+			}
+		}
+		return &RealDataCell[T]{T(count)}
+	}
+
+	if len(values) == 0 {
+		if a.Op == AggSum {
+			return &RealDataCell[T]{0}
+		}
+		return nil
+	}
+
+	var v T
+	switch a.Op {
+	case AggSum:
+		for _, x := range values {
+			v += x
+		}
+	case AggMean:
+		v = maths.Mean(slices.Values(values))
+	case AggVariance:
+		v, _, _ = maths.WelfordVar(slices.Values(values))
+	case AggMin:
+		v = slices.Min(values)
+	case AggMax:
+		v = slices.Max(values)
+	case AggMedian:
+		slices.Sort(values)
+		n := len(values)
+		v = values[n/2]
+		if n%2 == 0 {
+			v = (values[n/2-1] + values[n/2]) / 2
+		}
+	}
+
+	return &RealDataCell[T]{v}
+}
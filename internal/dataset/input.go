@@ -0,0 +1,220 @@
+package dataset
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const sniff_size = 64 * 1024
+
+var utf8_bom = []byte{0xef, 0xbb, 0xbf}
+
+type input_file struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (f *input_file) Close() error {
+	var err error
+	for _, c := range f.closers {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Reader of r, decompressed when it starts with the magic bytes of gzip or bzip2
+func decompress(r io.Reader) (io.Reader, io.Closer, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(4)
+
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, err
+		}
+		return gz, gz, nil
+	case len(magic) == 4 && bytes.HasPrefix(magic, []byte("BZh")) && '1' <= magic[3] && magic[3] <= '9': // block size digit
+		return bzip2.NewReader(br), nil, nil
+	}
+	return br, nil, nil
+}
+
+// Reader of r without its leading UTF-8 byte order mark if any. r is only buffered when it is not already
+func skip_bom(r io.Reader) io.Reader {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	if start, _ := br.Peek(len(utf8_bom)); bytes.Equal(start, utf8_bom) {
+		br.Discard(len(utf8_bom))
+	}
+	return br
+}
+
+// Open a file, decompressed on the fly. The byte order mark is left to the loaders reading it
+func open_input(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r, closer, err := decompress(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s : %w", path, err)
+	}
+
+	f := &input_file{Reader: r, closers: []io.Closer{file}}
+	if closer != nil {
+		f.closers = append([]io.Closer{closer}, f.closers...)
+	}
+	return f, nil
+}
+
+type CsvDialect struct {
+	Delim      rune
+	Quoted     bool // some fields are enclosed in double quotes
+	LazyQuotes bool // double quotes also show up inside unquoted fields, see csv.Reader.LazyQuotes
+}
+
+// Count of delim outside quoted fields, and whether the line holds quoted fields and stray quotes
+func scan_line(line string, delim rune) (count int, quoted bool, stray bool) {
+	field_start, in_quote := true, false
+	runes := []rune(line)
+
+	for k := 0; k < len(runes); k++ {
+		c := runes[k]
+		switch {
+		case in_quote:
+			if c == '"' {
+				if k+1 < len(runes) && runes[k+1] == '"' {
+					k++
+				} else {
+					in_quote = false
+				}
+			}
+		case c == delim:
+			count++
+			field_start = true
+			continue
+		case c == '"' && field_start:
+			in_quote, quoted = true, true
+		case c == '"':
+			stray = true
+		}
+		field_start = false
+	}
+
+	return count, quoted, stray
+}
+
+/*
+Guess the dialect of a CSV sample among the delimiters ',', ';', tab and '|' : the one found outside quotes
+the same number of times on most lines wins, ',' when none is found.
+truncated tells that the sample is the start of a longer input, so that its last line is ignored
+*/
+func SniffCsv(sample []byte, truncated bool) CsvDialect {
+	lines := strings.Split(strings.ReplaceAll(string(sample), "\r\n", "\n"), "\n")
+	if truncated && len(lines) > 1 {
+		lines = lines[:len(lines)-1]
+	}
+	lines = slices.DeleteFunc(lines, func(line string) bool {
+		return strings.TrimSpace(line) == ""
+	})
+
+	best := CsvDialect{Delim: ','}
+	best_lines, best_count := 0, 0
+
+	for _, delim := range []rune{',', ';', '\t', '|'} {
+		frequencies := make(map[int]int)
+		for _, line := range lines {
+			count, _, _ := scan_line(line, delim)
+			frequencies[count]++
+		}
+
+		// most frequent non zero count
+		lines_with, count := 0, 0
+		for c, n := range frequencies {
+			if c > 0 && (n > lines_with || (n == lines_with && c > count)) {
+				lines_with, count = n, c
+			}
+		}
+
+		if lines_with > best_lines || (lines_with == best_lines && lines_with > 0 && count > best_count) {
+			best.Delim, best_lines, best_count = delim, lines_with, count
+		}
+	}
+
+	for _, line := range lines {
+		_, quoted, stray := scan_line(line, best.Delim)
+		best.Quoted = best.Quoted || quoted
+		best.LazyQuotes = best.LazyQuotes || stray
+	}
+
+	return best
+}
+
+/*
+Load a CSV or a snapshot, see WriteSnapshot, whatever the compression : gzip and bzip2 are decompressed on the fly,
+a UTF-8 byte order mark is skipped and the CSV dialect is guessed by SniffCsv from the first lines
+*/
+func (ds *DataSet[T]) LoadAutoReader(r io.Reader) error {
+	r, closer, err := decompress(r)
+	if err != nil {
+		return fmt.Errorf("DataSet.LoadAutoReader : %w", err)
+	}
+	if closer != nil {
+		defer closer.Close()
+	}
+
+	br := bufio.NewReaderSize(skip_bom(r), sniff_size)
+	sample, _ := br.Peek(sniff_size)
+
+	if bytes.HasPrefix(sample, []byte(snapshot_magic)) {
+		return ds.LoadSnapshot(br)
+	}
+
+	dialect := SniffCsv(sample, len(sample) == sniff_size)
+
+	reader := csv.NewReader(br)
+	reader.Comma = dialect.Delim
+	reader.LazyQuotes = dialect.LazyQuotes
+	return ds.load_csv(reader)
+}
+
+/*
+Load a file whatever its format and compression : .json and .jsonl or .ndjson files, possibly followed by .gz or .bz2,
+go through LoadJSON and LoadJSONL, others through LoadAutoReader
+*/
+func (ds *DataSet[T]) LoadAuto(path string) error {
+	name := strings.ToLower(filepath.Base(path))
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".bz2")
+
+	switch filepath.Ext(name) {
+	case ".json":
+		return ds.LoadJSON(path)
+	case ".jsonl", ".ndjson":
+		return ds.LoadJSONL(path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return ds.LoadAutoReader(file)
+}
@@ -0,0 +1,312 @@
+package dataset
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/exp/constraints"
+)
+
+// New dataset over row major datas, names holding one name per column.
+// target must be one of the columns, -1 meaning the target was not carried over. weight names the sample weight column, if any
+func from_cells[T constraints.Float](names []string, target int, weight string, datas []DataCell) (*DataSet[T], error) {
+	if target < 0 || target >= len(names) {
+		return nil, errors.New("the target column is not part of the result")
+	}
+
+	ds := NewDataSet[T](uint32(target))
+	for _, name := range names {
+		ds.headers = append(ds.headers, header_t{name, true})
+	}
+
+	ds.datas = datas
+	ds.real_feat_indices = make([]int, max(0, len(names)-1))
+	ds.update_feat_indices()
+
+	if weight != "" {
+		if err := ds.SetWeightColumn(weight); err != nil {
+			return nil, err
+		}
+	}
+	return &ds, nil
+}
+
+// Indices of the used columns
+func (ds *DataSet[T]) used_columns() []int {
+	var cols []int
+	for j, h := range ds.headers {
+		if h.used {
+			cols = append(cols, j)
+		}
+	}
+	return cols
+}
+
+// Empty when nothing is loaded
+func (ds *DataSet[T]) target_name() string {
+	if int(ds.trg_col_idx) >= len(ds.headers) {
+		return ""
+	}
+	return ds.headers[ds.trg_col_idx].name
+}
+
+type JoinKind int
+
+const (
+	InnerJoin JoinKind = iota // matching rows only
+	LeftJoin                  // every row of the left dataset
+	RightJoin                 // every row of the right dataset
+	OuterJoin                 // every row of both
+)
+
+type JoinOptions struct {
+	On   []string // key columns, present in both datasets
+	Kind JoinKind
+
+	// Appended to the non key columns present in both datasets. Default to "_left" and "_right"
+	LeftSuffix  string
+	RightSuffix string
+}
+
+func (ds *DataSet[T]) key(sb *strings.Builder, i int, keys []int) bool {
+	sb.Reset()
+	for _, j := range keys {
+		c := ds.at(i, j)
+		if c == nil {
+			return false
+		}
+		key_of[T](c).write(sb)
+	}
+	return true
+}
+
+/*
+Hash join of ds (left) and other (right) on the key columns, compared by value. Like in SQL, a row with an empty key matches no row.
+The result holds the keys, then the used columns of ds, then the used columns of other. Keys of unmatched right rows come from other.
+Rows follow the order of ds, matches following the order of other, then unmatched right rows. The target and the weights of ds are kept
+*/
+func (ds *DataSet[T]) Join(other *DataSet[T], opts JoinOptions) (*DataSet[T], error) {
+	if len(opts.On) == 0 {
+		return nil, errors.New("DataSet.Join : no key column provided")
+	}
+
+	left_keys, err := ds.column_indices("Join", opts.On)
+	if err != nil {
+		return nil, err
+	}
+
+	right_keys, err := other.column_indices("Join", opts.On)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.LeftSuffix == "" {
+		opts.LeftSuffix = "_left"
+	}
+	if opts.RightSuffix == "" {
+		opts.RightSuffix = "_right"
+	}
+
+	is_key := func(name string) bool { return slices.Contains(opts.On, name) }
+	var left_cols, right_cols []int
+	for _, j := range ds.used_columns() {
+		if !is_key(ds.headers[j].name) {
+			left_cols = append(left_cols, j)
+		}
+	}
+	for _, j := range other.used_columns() {
+		if !is_key(other.headers[j].name) {
+			right_cols = append(right_cols, j)
+		}
+	}
+
+	names := slices.Clone(opts.On)
+	for _, j := range left_cols {
+		name := ds.headers[j].name
+		if other.ColumnIndex(name) != -1 && other.headers[other.ColumnIndex(name)].used {
+			name += opts.LeftSuffix
+		}
+		names = append(names, name)
+	}
+	for _, j := range right_cols {
+		name := other.headers[j].name
+		if ds.ColumnIndex(name) != -1 && ds.headers[ds.ColumnIndex(name)].used {
+			name += opts.RightSuffix
+		}
+		names = append(names, name)
+	}
+
+	for k, name := range names {
+		if slices.Contains(names[:k], name) {
+			return nil, fmt.Errorf("DataSet.Join : column %q produced twice", name)
+		}
+	}
+
+	target := slices.Index(opts.On, ds.target_name())
+	if k := slices.Index(left_cols, int(ds.trg_col_idx)); k != -1 {
+		target = len(opts.On) + k
+	}
+
+	// the weights of ds are kept, under their suffixed name if any
+	weight := ds.weight_name()
+	if k := slices.Index(left_cols, ds.weight_column()); ds.Weighted() && k != -1 {
+		weight = names[len(opts.On)+k]
+	}
+
+	var sb strings.Builder
+	lookup := make(map[string][]int)
+	right_start, right_end := other.min_bound(), other.max_bound()
+	for i := right_start; i < right_end; i++ {
+		if other.key(&sb, i, right_keys) {
+			lookup[sb.String()] = append(lookup[sb.String()], i)
+		}
+	}
+
+	width := len(names)
+	var datas []DataCell
+	matched := make([]bool, right_end-right_start)
+
+	append_row := func(l, r int) {
+		row := make([]DataCell, width)
+		for k := range opts.On {
+			if l >= 0 {
+				row[k] = ds.at(l, left_keys[k])
+			} else {
+				row[k] = other.at(r, right_keys[k])
+			}
+		}
+
+		if l >= 0 {
+			for k, j := range left_cols {
+				row[len(opts.On)+k] = ds.at(l, j)
+			}
+		}
+		if r >= 0 {
+			for k, j := range right_cols {
+				row[len(opts.On)+len(left_cols)+k] = other.at(r, j)
+			}
+		}
+
+		datas = append(datas, row...)
+	}
+
+	left_start, left_end := ds.min_bound(), ds.max_bound()
+	for i := left_start; i < left_end; i++ {
+		var matches []int
+		if ds.key(&sb, i, left_keys) {
+			matches = lookup[sb.String()]
+		}
+
+		for _, r := range matches {
+			matched[r-right_start] = true
+			append_row(i, r)
+		}
+
+		if len(matches) == 0 && (opts.Kind == LeftJoin || opts.Kind == OuterJoin) {
+			append_row(i, -1)
+		}
+	}
+
+	if opts.Kind == RightJoin || opts.Kind == OuterJoin {
+		for r := right_start; r < right_end; r++ {
+			if !matched[r-right_start] {
+				append_row(-1, r)
+			}
+		}
+	}
+
+	res, err := from_cells[T](names, target, weight, datas)
+	if err != nil {
+		return nil, fmt.Errorf("DataSet.Join : %w", err)
+	}
+	return res, nil
+}
+
+/*
+Stack the rows of the datasets. Columns are aligned by name, in order of first appearance,
+and are empty in the rows of the datasets lacking them. The target and the weights of the first dataset are kept
+*/
+func ConcatRows[T constraints.Float](sets ...*DataSet[T]) (*DataSet[T], error) {
+	if len(sets) == 0 {
+		return nil, errors.New("ConcatRows : no dataset provided")
+	}
+
+	var names []string
+	for _, ds := range sets {
+		for _, j := range ds.used_columns() {
+			if !slices.Contains(names, ds.headers[j].name) {
+				names = append(names, ds.headers[j].name)
+			}
+		}
+	}
+
+	var datas []DataCell
+	for _, ds := range sets {
+		cols := make([]int, len(names))
+		for k, name := range names {
+			cols[k] = ds.ColumnIndex(name)
+			if cols[k] != -1 && !ds.headers[cols[k]].used {
+				cols[k] = -1
+			}
+		}
+
+		start, end := ds.min_bound(), ds.max_bound()
+		for i := start; i < end; i++ {
+			for _, j := range cols {
+				if j == -1 {
+					datas = append(datas, nil)
+				} else {
+					datas = append(datas, ds.at(i, j))
+				}
+			}
+		}
+	}
+
+	res, err := from_cells[T](names, slices.Index(names, sets[0].target_name()), sets[0].weight_name(), datas)
+	if err != nil {
+		return nil, fmt.Errorf("ConcatRows : %w", err)
+	}
+	return res, nil
+}
+
+// Place the used columns of the datasets side by side. Datasets must have the same size and distinct column names.
+// The target and the weights of the first dataset are kept
+func ConcatColumns[T constraints.Float](sets ...*DataSet[T]) (*DataSet[T], error) {
+	if len(sets) == 0 {
+		return nil, errors.New("ConcatColumns : no dataset provided")
+	}
+
+	var names []string
+	cols := make([][]int, len(sets))
+	for k, ds := range sets {
+		if ds.Size() != sets[0].Size() {
+			return nil, fmt.Errorf("ConcatColumns : %d rows and %d rows", sets[0].Size(), ds.Size())
+		}
+
+		cols[k] = ds.used_columns()
+		for _, j := range cols[k] {
+			if slices.Contains(names, ds.headers[j].name) {
+				return nil, fmt.Errorf("ConcatColumns : column %q present twice", ds.headers[j].name)
+			}
+			names = append(names, ds.headers[j].name)
+		}
+	}
+
+	datas := make([]DataCell, 0, len(names)*int(sets[0].Size()))
+	for i := range int(sets[0].Size()) {
+		for k, ds := range sets {
+			row := ds.min_bound() + i
+			for _, j := range cols[k] {
+				datas = append(datas, ds.at(row, j))
+			}
+		}
+	}
+
+	res, err := from_cells[T](names, slices.Index(names, sets[0].target_name()), sets[0].weight_name(), datas)
+	if err != nil {
+		return nil, fmt.Errorf("ConcatColumns : %w", err)
+	}
+	return res, nil
+}
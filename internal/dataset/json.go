@@ -0,0 +1,302 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+
+	"golang.org/x/exp/constraints"
+)
+
+type json_cell struct {
+	col  int
+	cell DataCell
+}
+
+// Headers seen so far and rows of (column, cell) pairs, columns being added as new keys show up
+type json_loader[T constraints.Float] struct {
+	ds      *DataSet[T]
+	columns map[string]int
+	rows    [][]json_cell
+}
+
+func new_json_loader[T constraints.Float](ds *DataSet[T]) *json_loader[T] {
+	l := &json_loader[T]{ds: ds, columns: make(map[string]int)}
+	for j, h := range ds.headers {
+		l.columns[h.name] = j
+	}
+	return l
+}
+
+func (l *json_loader[T]) column(key string) int {
+	j, found := l.columns[key]
+	if !found {
+		j = len(l.ds.headers)
+		l.columns[key] = j
+		l.ds.headers = append(l.ds.headers, header_t{key, true})
+	}
+	return j
+}
+
+// Flatten the object into a row, see LoadJSONLReader
+func (l *json_loader[T]) add(raw json.RawMessage) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return errors.New("record is not an object")
+	}
+
+	var row []json_cell
+	if err := l.walk(dec, raw, "", &row); err != nil {
+		return err
+	}
+	l.rows = append(l.rows, row)
+	return nil
+}
+
+// Reads the members of an object whose '{' was just read
+func (l *json_loader[T]) walk(dec *json.Decoder, raw []byte, prefix string, row *[]json_cell) error {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := prefix + tok.(string)
+
+		tok, err = dec.Token()
+		if err != nil {
+			return err
+		}
+
+		var cell DataCell
+		switch v := tok.(type) {
+		case json.Delim:
+			if v == '{' {
+				if err := l.walk(dec, raw, key+".", row); err != nil {
+					return err
+				}
+				continue
+			}
+
+			start := dec.InputOffset()
+			if err := skip_array(dec); err != nil {
+				return err
+			}
+
+			array := append([]byte{'['}, raw[start:dec.InputOffset()]...)
+			var text bytes.Buffer
+			if err := json.Compact(&text, array); err != nil {
+				return err
+			}
+			cell = &StrDataCell{text.String()}
+		case json.Number:
+			cell = l.ds.parse_cell(v.String())
+		case string:
+			cell = l.ds.parse_cell(v)
+		case bool:
+			cell = l.ds.parse_cell(fmt.Sprint(v))
+		}
+
+		*row = append(*row, json_cell{l.column(key), cell})
+	}
+
+	_, err := dec.Token() // '}'
+	return err
+}
+
+// Skips the rest of an array whose '[' was just read
+func skip_array(dec *json.Decoder) error {
+	for depth := 1; depth > 0; {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch tok {
+		case json.Delim('['), json.Delim('{'):
+			depth++
+		case json.Delim(']'), json.Delim('}'):
+			depth--
+		}
+	}
+	return nil
+}
+
+// Move the rows into ds, keys missing from a record giving empty cells
+func (l *json_loader[T]) flush() error {
+	if len(l.ds.headers) == 0 {
+		return errors.New("no column found")
+	}
+
+	cols := len(l.ds.headers)
+	l.ds.datas = make([]DataCell, len(l.rows)*cols)
+	for i, row := range l.rows {
+		for _, c := range row {
+			l.ds.datas[i*cols+c.col] = c.cell
+		}
+	}
+	l.rows = nil
+
+	return l.ds.finish_load()
+}
+
+// Records of a JSON Lines stream, or of a JSON array when array is set, decoded one at a time.
+// A leading UTF-8 byte order mark is skipped
+func json_records(r io.Reader, array bool) iter.Seq2[json.RawMessage, error] {
+	return func(yield func(json.RawMessage, error) bool) {
+		dec := json.NewDecoder(skip_bom(r))
+		if array {
+			if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+				yield(nil, errors.New("input is not an array"))
+				return
+			}
+		}
+
+		for !array || dec.More() {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				if err != io.EOF || array {
+					yield(nil, err)
+				}
+				return
+			}
+
+			if !yield(raw, nil) {
+				return
+			}
+		}
+
+		if _, err := dec.Token(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+func (ds *DataSet[T]) load_json(r io.Reader, array bool) error {
+	l := new_json_loader(ds)
+	i := 0
+	for raw, err := range json_records(r, array) {
+		if err != nil {
+			return err
+		}
+
+		if err := l.add(raw); err != nil {
+			return fmt.Errorf("record %d : %w", i, err)
+		}
+		i++
+	}
+
+	return l.flush()
+}
+
+/*
+Load one JSON object per line. Headers are the union of the keys of every record, in order of appearance,
+missing keys giving empty cells. Nested objects are flattened into dotted keys like "user.address.city",
+arrays are kept as their JSON text and null is an empty cell. Other values are typed as CSV cells are, "12" giving a real cell.
+A leading UTF-8 byte order mark is skipped
+*/
+func (ds *DataSet[T]) LoadJSONLReader(r io.Reader) error {
+	if err := ds.load_json(r, false); err != nil {
+		return fmt.Errorf("DataSet.LoadJSONLReader : %w", err)
+	}
+	return nil
+}
+
+// gzip and bzip2 files are decompressed on the fly
+func (ds *DataSet[T]) LoadJSONL(path string) error {
+	file, err := open_input(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return ds.LoadJSONLReader(file)
+}
+
+// Load a JSON array of objects, see LoadJSONLReader
+func (ds *DataSet[T]) LoadJSONReader(r io.Reader) error {
+	if err := ds.load_json(r, true); err != nil {
+		return fmt.Errorf("DataSet.LoadJSONReader : %w", err)
+	}
+	return nil
+}
+
+// gzip and bzip2 files are decompressed on the fly
+func (ds *DataSet[T]) LoadJSON(path string) error {
+	file, err := open_input(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return ds.LoadJSONReader(file)
+}
+
+// New dataset sharing the loading options of ds
+func (ds *DataSet[T]) empty_like() *DataSet[T] {
+	return &DataSet[T]{
+		trg_col_idx:     ds.trg_col_idx,
+		trg_col_names:   ds.trg_col_names,
+		weight_col_name: ds.weight_col_name,
+		time_layouts:    ds.time_layouts,
+		max_range:       1.0,
+	}
+}
+
+/*
+Read a JSON Lines stream by chunks of chunk_size records, so that inputs larger than memory can be processed.
+Every chunk is a new dataset with the loading options of ds, which is left untouched. Its headers are the keys seen so far :
+columns keep their index from one chunk to the next and new keys are appended
+*/
+func (ds *DataSet[T]) StreamJSONL(r io.Reader, chunk_size int, cb func(chunk *DataSet[T]) error) error {
+	if chunk_size < 1 {
+		return errors.New("DataSet.StreamJSONL : chunk size must be positive")
+	}
+
+	var headers []header_t
+	next := func() *json_loader[T] {
+		chunk := ds.empty_like()
+		chunk.headers = headers
+		return new_json_loader(chunk)
+	}
+
+	l := next()
+	i := 0
+	for raw, err := range json_records(r, false) {
+		if err != nil {
+			return fmt.Errorf("DataSet.StreamJSONL : %w", err)
+		}
+
+		if err := l.add(raw); err != nil {
+			return fmt.Errorf("DataSet.StreamJSONL : record %d : %w", i, err)
+		}
+		i++
+
+		if len(l.rows) == chunk_size {
+			// the callback may drop columns of its chunk
+			headers = slices.Clone(l.ds.headers)
+			if err := l.flush(); err != nil {
+				return fmt.Errorf("DataSet.StreamJSONL : %w", err)
+			}
+
+			if err := cb(l.ds); err != nil {
+				return err
+			}
+			l = next()
+		}
+	}
+
+	if len(l.rows) == 0 {
+		return nil
+	}
+
+	if err := l.flush(); err != nil {
+		return fmt.Errorf("DataSet.StreamJSONL : %w", err)
+	}
+	return cb(l.ds)
+}
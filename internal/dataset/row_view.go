@@ -0,0 +1,60 @@
+package dataset
+
+import (
+	"golang.org/x/exp/constraints"
+)
+
+// Read only access to the cells of one row by column name
+type RowView[T constraints.Float] struct {
+	sample  DataSample[T]
+	columns map[string]int
+}
+
+// Raw cell of the column, nil when empty or when the column does not exist
+func (r RowView[T]) Get(name string) DataCell {
+	j, found := r.columns[name]
+	if !found {
+		return nil
+	}
+	return r.sample.At(j)
+}
+
+// Value of a real cell
+func (r RowView[T]) Real(name string) (T, bool) {
+	if c, ok := r.Get(name).(*RealDataCell[T]); ok {
+		return c.Value, true
+	}
+	return 0.0, false
+}
+
+// Value of a string cell
+func (r RowView[T]) Str(name string) (string, bool) {
+	if c, ok := r.Get(name).(*StrDataCell); ok {
+		return c.Value, true
+	}
+	return "", false
+}
+
+func (r RowView[T]) Sample() DataSample[T] {
+	return r.sample
+}
+
+func (ds *DataSet[T]) column_lookup() map[string]int {
+	columns := make(map[string]int, len(ds.headers))
+	for j, h := range ds.headers {
+		columns[h.name] = j
+	}
+	return columns
+}
+
+// Append a column computed from every row. Same storage rules as AddColumn
+func (ds *DataSet[T]) DeriveColumn(name string, cb func(row RowView[T]) DataCell) error {
+	columns := ds.column_lookup()
+	values := make([]DataCell, 0, ds.Size())
+
+	for s := range ds.Samples() {
+		values = append(values, cb(RowView[T]{s, columns}))
+	}
+
+	return ds.AddColumn(name, values)
+}
@@ -0,0 +1,108 @@
+package dataset
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// View of the rows for which pred holds. The view shares the underlying datas with ds
+func (ds *DataSet[T]) Filter(pred func(s DataSample[T]) bool) *DataSet[T] {
+	start := ds.min_bound()
+	rows := []int{}
+
+	for s := range ds.Samples() {
+		if pred(s) {
+			rows = append(rows, s.row-start)
+		}
+	}
+
+	view, _ := ds.Select(rows)
+	return view
+}
+
+func (ds *DataSet[T]) column_indices(method string, columns []string) ([]int, error) {
+	indices := make([]int, len(columns))
+	for k, name := range columns {
+		if indices[k] = ds.ColumnIndex(name); indices[k] == -1 {
+			return nil, fmt.Errorf("DataSet.%s : column %q not found", method, name)
+		}
+	}
+	return indices, nil
+}
+
+/*
+View of the rows sorted by columns, the first one being the primary key. The sort is stable.
+ascending holds one direction per column, or a single one for every column.
+The direction only applies to cells of the same kind : reals come before strings, strings before times
+and empty cells come last, whatever the direction
+*/
+func (ds *DataSet[T]) SortBy(columns []string, ascending []bool) (*DataSet[T], error) {
+	indices, err := ds.column_indices("SortBy", columns)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ascending) != 1 && len(ascending) != len(columns) {
+		return nil, fmt.Errorf("DataSet.SortBy : %d directions given for %d columns", len(ascending), len(columns))
+	}
+
+	start := ds.min_bound()
+	rows := make([]int, ds.Size())
+	for i := range rows {
+		rows[i] = i
+	}
+
+	slices.SortStableFunc(rows, func(a, b int) int {
+		for k, j := range indices {
+			ca, cb := ds.at(start+a, j), ds.at(start+b, j)
+			c := compare_cells[T](ca, cb)
+			if c == 0 {
+				continue
+			}
+
+			if key_of[T](ca).kind == key_of[T](cb).kind && !ascending[min(k, len(ascending)-1)] {
+				c = -c
+			}
+			return c
+		}
+		return 0
+	})
+
+	return ds.Select(rows)
+}
+
+// View without the rows repeating the values of an earlier row on subset. An empty subset compares every used column
+func (ds *DataSet[T]) DropDuplicates(subset []string) (*DataSet[T], error) {
+	indices, err := ds.column_indices("DropDuplicates", subset)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(subset) == 0 {
+		for j, h := range ds.headers {
+			if h.used {
+				indices = append(indices, j)
+			}
+		}
+	}
+
+	start := ds.min_bound()
+	seen := make(map[string]struct{})
+	rows := []int{}
+	var sb strings.Builder
+
+	for i := range int(ds.Size()) {
+		sb.Reset()
+		for _, j := range indices {
+			key_of[T](ds.at(start+i, j)).write(&sb)
+		}
+
+		if _, found := seen[sb.String()]; !found {
+			seen[sb.String()] = struct{}{}
+			rows = append(rows, i)
+		}
+	}
+
+	return ds.Select(rows)
+}
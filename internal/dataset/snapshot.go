@@ -0,0 +1,337 @@
+package dataset
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/bleak-and-bare/machine_learning/internal/maths/sparse"
+)
+
+/*
+Native binary format. Integers are uvarints and floats little endian, of the float size of the header :
+
+	"MLDS" version float_size
+	column_count { name } target_count { target_column } weight_column+1 row_count
+	one block per column holding its cells in row order, each one being a tag followed by its value :
+	0 empty, 1 real, 2 string, 3 time as encoded by time.Time.MarshalBinary
+	sparse_column_count [ nnz { row_pointer } { column } { value } ] when there are sparse features
+
+Strings and names are a length followed by their bytes
+*/
+const (
+	snapshot_magic   = "MLDS"
+	snapshot_version = 1
+)
+
+type snapshot_writer struct {
+	w   *bufio.Writer
+	buf []byte
+	err error
+}
+
+func (s *snapshot_writer) uint(v uint64) {
+	s.buf = binary.AppendUvarint(s.buf[:0], v)
+	s.bytes(s.buf)
+}
+
+func (s *snapshot_writer) bytes(b []byte) {
+	if s.err == nil {
+		_, s.err = s.w.Write(b)
+	}
+}
+
+func (s *snapshot_writer) str(v string) {
+	s.uint(uint64(len(v)))
+	s.bytes([]byte(v))
+}
+
+func (s *snapshot_writer) float(v float64, size int) {
+	if size == 4 {
+		s.buf = binary.LittleEndian.AppendUint32(s.buf[:0], math.Float32bits(float32(v)))
+	} else {
+		s.buf = binary.LittleEndian.AppendUint64(s.buf[:0], math.Float64bits(v))
+	}
+	s.bytes(s.buf)
+}
+
+/*
+Write the active view in the native binary format, sparse features, targets and sample weights included.
+LoadSnapshot reads it back without parsing any text
+*/
+func (ds *DataSet[T]) WriteSnapshot(w io.Writer) error {
+	s := snapshot_writer{w: bufio.NewWriter(w)}
+	size := float_bits[T]() / 8
+
+	s.bytes([]byte(snapshot_magic))
+	s.bytes([]byte{snapshot_version, byte(size)})
+
+	cols := ds.used_indices()
+	s.uint(uint64(len(cols)))
+	for _, j := range cols {
+		s.str(ds.headers[j].name)
+	}
+
+	targets := ds.targets()
+	s.uint(uint64(len(targets)))
+	for _, j := range targets {
+		s.uint(uint64(slices.Index(cols, j)))
+	}
+	s.uint(uint64(slices.Index(cols, ds.weight_column()) + 1))
+
+	start, end := ds.min_bound(), ds.max_bound()
+	s.uint(uint64(end - start))
+
+	for _, j := range cols {
+		for i := start; i < end; i++ {
+			switch c := ds.at(i, j).(type) {
+			case *RealDataCell[T]:
+				s.bytes([]byte{1})
+				s.float(float64(c.Value), size)
+			case *StrDataCell:
+				s.bytes([]byte{2})
+				s.str(c.Value)
+			case *TimeDataCell:
+				b, err := c.Value.MarshalBinary()
+				if err != nil {
+					return err
+				}
+				s.bytes([]byte{3})
+				s.str(string(b))
+			default:
+				s.bytes([]byte{0})
+			}
+		}
+	}
+
+	m := ds.SparseFeatures()
+	if m == nil {
+		s.uint(0)
+	} else {
+		s.uint(uint64(m.Cols()))
+		s.uint(uint64(m.NNZ()))
+		for _, p := range m.Indptr {
+			s.uint(uint64(p))
+		}
+		for _, j := range m.Indices {
+			s.uint(uint64(j))
+		}
+		for _, v := range m.Values {
+			s.float(float64(v), size)
+		}
+	}
+
+	if s.err != nil {
+		return s.err
+	}
+	return s.w.Flush()
+}
+
+type snapshot_reader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (s *snapshot_reader) uint() int {
+	if s.err != nil {
+		return 0
+	}
+
+	v, err := binary.ReadUvarint(s.r)
+	if err == nil && v > math.MaxInt32 {
+		err = errors.New("corrupted length")
+	}
+	s.err = err
+	return int(v)
+}
+
+// Grows while reading, so that a corrupted length does not allocate more than what the input holds
+func (s *snapshot_reader) bytes(n int) []byte {
+	if s.err != nil {
+		return nil
+	}
+
+	b, err := io.ReadAll(io.LimitReader(s.r, int64(n)))
+	if err == nil && len(b) < n {
+		err = io.ErrUnexpectedEOF
+	}
+	s.err = err
+	return b
+}
+
+// Initial capacity of a slice of n elements read from the input, which grows as they are read
+func read_cap(n int) int {
+	return min(n, 4096)
+}
+
+func (s *snapshot_reader) str() string {
+	return string(s.bytes(s.uint()))
+}
+
+func (s *snapshot_reader) float(size int) float64 {
+	b := s.bytes(size)
+	if s.err != nil {
+		return 0
+	}
+
+	if size == 4 {
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(b))
+}
+
+/*
+Load a snapshot written by WriteSnapshot, whatever its float size. Targets and sample weights are the saved ones,
+unless picked with WithTarget, WithTargets or WithWeights
+*/
+func (ds *DataSet[T]) LoadSnapshot(r io.Reader) error {
+	s := snapshot_reader{r: bufio.NewReader(r)}
+
+	header := s.bytes(len(snapshot_magic) + 2)
+	if s.err != nil || string(header[:len(snapshot_magic)]) != snapshot_magic {
+		return errors.New("DataSet.LoadSnapshot : not a snapshot")
+	}
+
+	if header[len(snapshot_magic)] != snapshot_version {
+		return fmt.Errorf("DataSet.LoadSnapshot : unsupported version %d", header[len(snapshot_magic)])
+	}
+
+	size := int(header[len(snapshot_magic)+1])
+	if size != 4 && size != 8 {
+		return fmt.Errorf("DataSet.LoadSnapshot : unsupported float size %d", size)
+	}
+
+	n := s.uint()
+	names := make([]string, 0, read_cap(n))
+	for range n {
+		if names = append(names, s.str()); s.err != nil {
+			break
+		}
+	}
+
+	n = s.uint()
+	if n > len(names) && s.err == nil {
+		s.err = errors.New("more targets than columns")
+	}
+
+	targets := make([]string, 0, n)
+	for range n {
+		if j := s.uint(); j < len(names) {
+			targets = append(targets, names[j])
+		} else if s.err == nil {
+			s.err = errors.New("target out of range")
+		}
+	}
+
+	weights := s.uint() - 1
+	rows := s.uint()
+
+	if s.err != nil {
+		return fmt.Errorf("DataSet.LoadSnapshot : %w", s.err)
+	}
+
+	if len(names) == 0 || len(targets) == 0 || weights >= len(names) {
+		return errors.New("DataSet.LoadSnapshot : corrupted schema")
+	}
+
+	if rows > math.MaxInt32/len(names) {
+		return fmt.Errorf("DataSet.LoadSnapshot : %d rows of %d columns is too large", rows, len(names))
+	}
+
+	headers := make([]header_t, len(names))
+	for j, name := range names {
+		headers[j] = header_t{name, true}
+	}
+
+	// cells are stored column by column
+	columns := make([][]DataCell, len(names))
+	for j := range names {
+		columns[j] = make([]DataCell, 0, read_cap(rows))
+		for range rows {
+			tag := s.bytes(1)
+			if s.err != nil {
+				return fmt.Errorf("DataSet.LoadSnapshot : %w", s.err)
+			}
+
+			var cell DataCell
+			switch tag[0] {
+			case 0:
+			case 1:
+				cell = &RealDataCell[T]{T(s.float(size))}
+			case 2:
+				cell = &StrDataCell{s.str()}
+			case 3:
+				var t time.Time
+				if err := t.UnmarshalBinary(s.bytes(s.uint())); err != nil && s.err == nil {
+					s.err = err
+				}
+				cell = &TimeDataCell{t}
+			default:
+				return fmt.Errorf("DataSet.LoadSnapshot : unknown cell tag %d", tag[0])
+			}
+			columns[j] = append(columns[j], cell)
+		}
+	}
+
+	datas := make([]DataCell, rows*len(names))
+	for j, column := range columns {
+		for i, cell := range column {
+			datas[i*len(names)+j] = cell
+		}
+	}
+
+	var m *sparse.CSR[T]
+	if cols := s.uint(); cols > 0 {
+		nnz := s.uint()
+		indptr := make([]int, 0, read_cap(rows+1))
+		indices := make([]int, 0, read_cap(nnz))
+		values := make([]T, 0, read_cap(nnz))
+
+		for k := 0; k <= rows && s.err == nil; k++ {
+			indptr = append(indptr, s.uint())
+		}
+		for k := 0; k < nnz && s.err == nil; k++ {
+			indices = append(indices, s.uint())
+		}
+		for k := 0; k < nnz && s.err == nil; k++ {
+			values = append(values, T(s.float(size)))
+		}
+
+		if s.err == nil {
+			csr, err := sparse.FromParts(rows, cols, indptr, indices, values)
+			s.err = err
+			m = &csr
+		}
+	}
+
+	if s.err != nil {
+		return fmt.Errorf("DataSet.LoadSnapshot : %w", s.err)
+	}
+
+	ds.headers = headers
+	ds.datas = datas
+	ds.rows = nil
+	ds.min_range, ds.max_range = 0.0, 1.0
+	ds.sparse_feats = nil
+
+	if ds.trg_col_names == nil {
+		ds.trg_col_names = targets
+	}
+	if ds.weight_col_name == "" && weights >= 0 {
+		ds.weight_col_name = names[weights]
+	}
+
+	if err := ds.finish_load(); err != nil {
+		return err
+	}
+
+	if m != nil {
+		return ds.AddSparseFeatures(m)
+	}
+	return nil
+}
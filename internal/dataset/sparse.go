@@ -0,0 +1,156 @@
+package dataset
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/bleak-and-bare/machine_learning/internal/maths/sparse"
+)
+
+// Storage rows of the dataset, in order
+func (ds *DataSet[T]) phys_rows() []int {
+	start, end := ds.min_bound(), ds.max_bound()
+	rows := make([]int, 0, end-start)
+	for i := start; i < end; i++ {
+		rows = append(rows, ds.phys_row(i))
+	}
+	return rows
+}
+
+func (ds *DataSet[T]) sparse_feat(row, feat int) *T {
+	if ds.sparse_feats == nil || feat >= ds.sparse_feats.Cols() {
+		return nil
+	}
+
+	if v := ds.sparse_feats.Ref(ds.phys_row(row), feat); v != nil {
+		return v
+	}
+	return new(T)
+}
+
+/*
+Append the columns of m, one row per row of the dataset, as features following the dense ones.
+Zeros are not stored, so wide features like bags of words do not need a cell each.
+Views, Copy and the column operations keep them, datasets built from several others (Join, ConcatRows, GroupBy...) do not.
+Same storage rules as AddColumn
+*/
+func (ds *DataSet[T]) AddSparseFeatures(m *sparse.CSR[T]) error {
+	if m.Rows() != int(ds.Size()) {
+		return fmt.Errorf("DataSet.AddSparseFeatures : %d rows provided for %d rows", m.Rows(), ds.Size())
+	}
+
+	if ds.rows != nil || ds.min_bound() != 0 || ds.max_bound() != int(ds.raw_count()) {
+		cols := make([]int, len(ds.headers))
+		for j := range cols {
+			cols[j] = j
+		}
+		ds.relayout(cols)
+	}
+
+	if ds.sparse_feats == nil {
+		sparse_feats := m.Clone()
+		ds.sparse_feats = &sparse_feats
+		return nil
+	}
+
+	sparse_feats, err := sparse.HStack(ds.sparse_feats, m)
+	if err != nil {
+		return fmt.Errorf("DataSet.AddSparseFeatures : %w", err)
+	}
+	ds.sparse_feats = &sparse_feats
+	return nil
+}
+
+// Replace every sparse feature by the columns of m. See AddSparseFeatures
+func (ds *DataSet[T]) SetSparseFeatures(m *sparse.CSR[T]) error {
+	if m.Rows() != int(ds.Size()) {
+		return fmt.Errorf("DataSet.SetSparseFeatures : %d rows provided for %d rows", m.Rows(), ds.Size())
+	}
+
+	ds.ClearSparseFeatures()
+	return ds.AddSparseFeatures(m)
+}
+
+func (ds *DataSet[T]) ClearSparseFeatures() {
+	ds.sparse_feats = nil
+}
+
+func (ds *DataSet[T]) SparseFeatCount() int {
+	if ds.sparse_feats == nil {
+		return 0
+	}
+	return ds.sparse_feats.Cols()
+}
+
+// Sparse features of the rows of the dataset, in order. Nil when there are none
+func (ds *DataSet[T]) SparseFeatures() *sparse.CSR[T] {
+	if ds.sparse_feats == nil {
+		return nil
+	}
+
+	m := ds.sparse_feats.SelectRows(ds.phys_rows())
+	return &m
+}
+
+/*
+Move real columns into the sparse features, freeing their cells, which suits one-hot and count columns that are mostly zeros.
+Every dense feature column when none is given. Moved columns are no longer listed by GetColumnNames,
+their features follow the sparse features already there. Empty and non real cells are not allowed, see FillEmpties.
+Same storage rules as AddColumn
+*/
+func (ds *DataSet[T]) Sparsify(columns ...string) error {
+	var moved []int
+	if len(columns) == 0 {
+		moved = slices.Clone(ds.real_feat_indices[:ds.dense_feat_count()])
+	} else {
+		for _, name := range columns {
+			j := ds.ColumnIndex(name)
+			if j == -1 || !ds.headers[j].used {
+				return fmt.Errorf("DataSet.Sparsify : column %q not found", name)
+			}
+
+			if ds.is_target(j) || j == ds.weight_column() {
+				return fmt.Errorf("DataSet.Sparsify : column %q is not a feature", name)
+			}
+
+			if slices.Contains(moved, j) {
+				return fmt.Errorf("DataSet.Sparsify : column %q listed twice", name)
+			}
+			moved = append(moved, j)
+		}
+	}
+
+	if len(moved) == 0 {
+		return nil
+	}
+
+	m := sparse.NewCSR[T](len(moved))
+	indices := make([]int, 0, len(moved))
+	values := make([]T, 0, len(moved))
+
+	for i := ds.min_bound(); i < ds.max_bound(); i++ {
+		indices, values = indices[:0], values[:0]
+		for k, j := range moved {
+			c, ok := ds.at(i, j).(*RealDataCell[T])
+			if !ok {
+				return fmt.Errorf("DataSet.Sparsify : non real cell <%d, %q>", i-ds.min_bound(), ds.headers[j].name)
+			}
+
+			if c.Value != 0 {
+				indices = append(indices, k)
+				values = append(values, c.Value)
+			}
+		}
+		m.AppendRow(indices, values)
+	}
+
+	kept := make([]int, 0, len(ds.headers)-len(moved))
+	for j := range ds.headers {
+		if !slices.Contains(moved, j) {
+			kept = append(kept, j)
+		}
+	}
+	ds.remap_columns(kept)
+
+	return ds.AddSparseFeatures(&m)
+}
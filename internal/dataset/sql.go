@@ -0,0 +1,266 @@
+package dataset
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/constraints"
+)
+
+// How the values of a column are scanned, from its sql.ColumnType
+type sql_kind int
+
+const (
+	sql_any sql_kind = iota // unknown scan type : typed from the driver value, text being typed as CSV cells are
+	sql_real
+	sql_bool // 1 or 0
+	sql_time
+	sql_text
+	sql_decimal // exact numbers that drivers scan as text, typed as CSV cells are
+)
+
+var (
+	null_time_type   = reflect.TypeFor[sql.NullTime]()
+	null_string_type = reflect.TypeFor[sql.NullString]()
+	null_bool_type   = reflect.TypeFor[sql.NullBool]()
+	time_type        = reflect.TypeFor[time.Time]()
+)
+
+// Database types of exact numbers, which drivers often scan as strings to keep their precision
+var decimal_types = []string{"DECIMAL", "NUMERIC", "NUMBER", "MONEY"}
+
+func sql_kind_of(ct *sql.ColumnType) sql_kind {
+	if slices.Contains(decimal_types, strings.ToUpper(ct.DatabaseTypeName())) {
+		return sql_decimal
+	}
+
+	t := ct.ScanType()
+	if t == nil {
+		return sql_any
+	}
+
+	switch t {
+	case null_time_type, time_type:
+		return sql_time
+	case null_string_type:
+		return sql_text
+	case null_bool_type:
+		return sql_bool
+	case reflect.TypeFor[sql.NullFloat64](), reflect.TypeFor[sql.NullInt64](), reflect.TypeFor[sql.NullInt32](),
+		reflect.TypeFor[sql.NullInt16](), reflect.TypeFor[sql.NullByte]():
+		return sql_real
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return sql_real
+	case reflect.Bool:
+		return sql_bool
+	case reflect.String:
+		return sql_text
+	}
+	return sql_any
+}
+
+// Scans the rows of a query into cells, NULL giving empty cells
+type sql_reader[T constraints.Float] struct {
+	rows  *sql.Rows
+	names []string
+	kinds []sql_kind
+	dest  []any
+}
+
+func new_sql_reader[T constraints.Float](rows *sql.Rows) (*sql_reader[T], error) {
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(names) == 0 {
+		return nil, errors.New("query returns no column")
+	}
+
+	r := &sql_reader[T]{rows: rows, names: names, kinds: make([]sql_kind, len(names)), dest: make([]any, len(names))}
+	for j, ct := range types {
+		r.kinds[j] = sql_kind_of(ct)
+		switch r.kinds[j] {
+		case sql_real:
+			r.dest[j] = new(sql.NullFloat64)
+		case sql_bool:
+			r.dest[j] = new(sql.NullBool)
+		case sql_time:
+			r.dest[j] = new(sql.NullTime)
+		case sql_text, sql_decimal:
+			r.dest[j] = new(sql.NullString)
+		default:
+			r.dest[j] = new(any)
+		}
+	}
+
+	return r, nil
+}
+
+// Appends the cells of the current row
+func (r *sql_reader[T]) scan(ds *DataSet[T]) error {
+	if err := r.rows.Scan(r.dest...); err != nil {
+		return err
+	}
+
+	for j, d := range r.dest {
+		var cell DataCell
+		switch v := d.(type) {
+		case *sql.NullFloat64:
+			if v.Valid {
+				cell = &RealDataCell[T]{T(v.Float64)}
+			}
+		case *sql.NullBool:
+			if v.Valid {
+				cell = bool_cell[T](v.Bool)
+			}
+		case *sql.NullTime:
+			if v.Valid {
+				cell = &TimeDataCell{v.Time}
+			}
+		case *sql.NullString:
+			if v.Valid && r.kinds[j] == sql_decimal {
+				cell = ds.parse_cell(v.String)
+			} else if v.Valid {
+				cell = &StrDataCell{v.String}
+			}
+		case *any:
+			cell = any_cell(ds, *v)
+		}
+		ds.datas = append(ds.datas, cell)
+	}
+
+	return nil
+}
+
+func bool_cell[T constraints.Float](b bool) DataCell {
+	if b {
+		return &RealDataCell[T]{1}
+	}
+	return &RealDataCell[T]{0}
+}
+
+// Cell of a value returned by a driver, see database/sql/driver.Value
+func any_cell[T constraints.Float](ds *DataSet[T], v any) DataCell {
+	switch v := v.(type) {
+	case int64:
+		return &RealDataCell[T]{T(v)}
+	case float64:
+		return &RealDataCell[T]{T(v)}
+	case bool:
+		return bool_cell[T](v)
+	case time.Time:
+		return &TimeDataCell{v}
+	case []byte:
+		return ds.parse_cell(string(v))
+	case string:
+		return ds.parse_cell(v)
+	case nil:
+		return nil
+	}
+	return ds.parse_cell(fmt.Sprint(v))
+}
+
+/*
+Load the rows of a query. Headers are the column names and cells are typed from the column types :
+numbers are real cells, decimals included, booleans 1 or 0, times are time cells and strings are string cells.
+Columns of an unknown type are typed from the values returned by the driver, text being typed as CSV cells are.
+NULL gives an empty cell
+*/
+func (ds *DataSet[T]) LoadSQL(ctx context.Context, db *sql.DB, query string, args ...any) error {
+	err := ds.stream_sql(ctx, db, 0, func(chunk *DataSet[T]) error {
+		ds.headers, ds.datas = chunk.headers, chunk.datas
+		return nil
+	}, query, args...)
+
+	if err != nil {
+		return fmt.Errorf("DataSet.LoadSQL : %w", err)
+	}
+	return ds.finish_load()
+}
+
+/*
+Run the query and hand its rows by chunks of chunk_size rows, so that results larger than memory can be processed.
+Every chunk is a new dataset with the loading options of ds, which is left untouched. See LoadSQL for the cell types
+*/
+func (ds *DataSet[T]) StreamSQL(ctx context.Context, db *sql.DB, chunk_size int, cb func(chunk *DataSet[T]) error, query string, args ...any) error {
+	if chunk_size < 1 {
+		return errors.New("DataSet.StreamSQL : chunk size must be positive")
+	}
+
+	err := ds.stream_sql(ctx, db, chunk_size, func(chunk *DataSet[T]) error {
+		if err := chunk.finish_load(); err != nil {
+			return err
+		}
+		return cb(chunk)
+	}, query, args...)
+
+	if err != nil {
+		return fmt.Errorf("DataSet.StreamSQL : %w", err)
+	}
+	return nil
+}
+
+// Chunks are not finished, a chunk_size of 0 reads every row into one chunk
+func (ds *DataSet[T]) stream_sql(ctx context.Context, db *sql.DB, chunk_size int, cb func(chunk *DataSet[T]) error, query string, args ...any) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	r, err := new_sql_reader[T](rows)
+	if err != nil {
+		return err
+	}
+
+	next := func() *DataSet[T] {
+		chunk := ds.empty_like()
+		chunk.headers = make([]header_t, len(r.names))
+		for j, name := range r.names {
+			chunk.headers[j] = header_t{name, true}
+		}
+		return chunk
+	}
+
+	chunk := next()
+	count := 0
+	for rows.Next() {
+		if err := r.scan(chunk); err != nil {
+			return err
+		}
+		count++
+
+		if count == chunk_size {
+			if err := cb(chunk); err != nil {
+				return err
+			}
+			chunk, count = next(), 0
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if count > 0 || chunk_size == 0 {
+		return cb(chunk)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package dataset_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+)
+
+// In memory driver serving one table, the first argument of a query filtering the rows on their first column
+type fake_driver struct{}
+
+type fake_conn struct{}
+
+type fake_stmt struct{}
+
+type fake_rows struct {
+	rows [][]driver.Value
+}
+
+var fake_columns = []string{"id", "name", "score", "active", "joined", "misc", "price"}
+
+var fake_types = []reflect.Type{
+	reflect.TypeFor[int64](),
+	reflect.TypeFor[sql.NullString](),
+	reflect.TypeFor[float64](),
+	reflect.TypeFor[bool](),
+	reflect.TypeFor[sql.NullTime](),
+	nil,
+	reflect.TypeFor[sql.NullString](),
+}
+
+var fake_db_types = []string{"BIGINT", "VARCHAR", "DOUBLE", "BOOLEAN", "TIMESTAMP", "", "DECIMAL"}
+
+var fake_table = [][]driver.Value{
+	{int64(1), "ann", 2.5, true, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), []byte("12.5"), "19.90"},
+	{int64(2), nil, 3.0, false, nil, "abc", nil},
+	{int64(3), "bob", nil, true, time.Date(2023, 6, 7, 0, 0, 0, 0, time.UTC), nil, "7"},
+}
+
+func init() {
+	sql.Register("dataset_fake", fake_driver{})
+}
+
+func (fake_driver) Open(string) (driver.Conn, error) { return fake_conn{}, nil }
+
+func (fake_conn) Prepare(string) (driver.Stmt, error) { return fake_stmt{}, nil }
+func (fake_conn) Close() error                        { return nil }
+func (fake_conn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (fake_stmt) Close() error  { return nil }
+func (fake_stmt) NumInput() int { return -1 }
+func (fake_stmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (fake_stmt) Query(args []driver.Value) (driver.Rows, error) {
+	var rows [][]driver.Value
+	for _, row := range fake_table {
+		if len(args) == 0 || row[0].(int64) > args[0].(int64) {
+			rows = append(rows, row)
+		}
+	}
+	return &fake_rows{rows}, nil
+}
+
+func (r *fake_rows) Columns() []string { return fake_columns }
+func (r *fake_rows) Close() error      { return nil }
+
+func (r *fake_rows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func (r *fake_rows) ColumnTypeScanType(j int) reflect.Type { return fake_types[j] }
+
+func (r *fake_rows) ColumnTypeDatabaseTypeName(j int) string { return fake_db_types[j] }
+
+func TestDataSet_LoadSQL(t *testing.T) {
+	db, err := sql.Open("dataset_fake", "")
+	if err != nil {
+		t.Fatalf("Failed to open the fake database : %v", err)
+	}
+	defer db.Close()
+
+	ds := dataset.NewDataSet[float64](0, dataset.WithTarget("score"))
+	if err := ds.LoadSQL(context.Background(), db, "SELECT * FROM users"); err != nil {
+		t.Errorf("DataSet.LoadSQL should not error : %v", err)
+		t.FailNow()
+	}
+
+	var sb strings.Builder
+	ds.WriteCsv(&sb, dataset.CsvOptions{NA: "NA"})
+	expected := `id,name,score,active,joined,misc,price
+1,ann,2.5,1,2024-01-02T03:04:05Z,12.5,19.9
+2,NA,3,0,NA,abc,NA
+3,bob,NA,1,2023-06-07T00:00:00Z,NA,7
+`
+	if sb.String() != expected {
+		t.Errorf("Wrong cells :\n%s", sb.String())
+	}
+
+	for s := range ds.Samples() {
+		if s.GetRow() == 0 && (*s.GetTarget() != 2.5 || *s.GetFeat(4) != 12.5 || s.GetFeat(5) == nil || *s.GetFeat(5) != 19.9) {
+			t.Error("Numbers should be real cells, decimals scanned as text included")
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	canceled := dataset.NewDataSet[float64](0)
+	if err := canceled.LoadSQL(ctx, db, "SELECT * FROM users"); err == nil {
+		t.Error("Should stop on a canceled context")
+	}
+}
+
+func TestDataSet_StreamSQL(t *testing.T) {
+	db, _ := sql.Open("dataset_fake", "")
+	defer db.Close()
+
+	ds := dataset.NewDataSet[float32](0, dataset.WithTarget("id"))
+	var ids []float32
+	var sizes []uint32
+
+	err := ds.StreamSQL(context.Background(), db, 1, func(chunk *dataset.DataSet[float32]) error {
+		sizes = append(sizes, chunk.Size())
+		for s := range chunk.Samples() {
+			ids = append(ids, *s.GetTarget())
+		}
+		return nil
+	}, "SELECT * FROM users WHERE id > ?", int64(1))
+
+	if err != nil {
+		t.Errorf("DataSet.StreamSQL should not error : %v", err)
+		t.FailNow()
+	}
+
+	if !slices.Equal(sizes, []uint32{1, 1}) || !slices.Equal(ids, []float32{2, 3}) {
+		t.Errorf("Wrong chunks : %v, %v", sizes, ids)
+	}
+
+	stop := errors.New("stop")
+	err = ds.StreamSQL(context.Background(), db, 2, func(*dataset.DataSet[float32]) error { return stop }, "SELECT * FROM users")
+	if !errors.Is(err, stop) {
+		t.Errorf("Callback errors should stop the stream : %v", err)
+	}
+}
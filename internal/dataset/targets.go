@@ -0,0 +1,98 @@
+package dataset
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+func (ds *DataSet[T]) is_target(j int) bool {
+	return j == int(ds.trg_col_idx) || slices.Contains(ds.trg_cols, j)
+}
+
+// Target column indices, the primary target first
+func (ds *DataSet[T]) targets() []int {
+	if ds.trg_cols != nil {
+		return ds.trg_cols
+	}
+	return []int{int(ds.trg_col_idx)}
+}
+
+/*
+Make every given column a target, none of them being a feature anymore.
+The first one stays the primary target used by GetTarget, TargetColumn and the single output models.
+On a view, the parent dataset keeps its targets
+*/
+func (ds *DataSet[T]) SetTargets(names ...string) error {
+	if len(names) == 0 {
+		return errors.New("DataSet.SetTargets : no column provided")
+	}
+
+	cols := make([]int, len(names))
+	for k, name := range names {
+		j := ds.ColumnIndex(name)
+		if j == -1 || !ds.headers[j].used {
+			return fmt.Errorf("DataSet.SetTargets : column %q not found", name)
+		}
+
+		if j == ds.weight_column() {
+			return fmt.Errorf("DataSet.SetTargets : column %q holds the sample weights", name)
+		}
+
+		if slices.Contains(cols[:k], j) {
+			return fmt.Errorf("DataSet.SetTargets : column %q listed twice", name)
+		}
+		cols[k] = j
+	}
+
+	ds.trg_col_idx = uint32(cols[0])
+	ds.trg_cols = nil
+	if len(cols) > 1 {
+		ds.trg_cols = cols
+	}
+	ds.trg_col_names = nil
+
+	// views share real_feat_indices with their parent
+	ds.real_feat_indices = make([]int, len(ds.headers)-1)
+	ds.update_feat_indices()
+
+	return nil
+}
+
+func (ds *DataSet[T]) TargetCount() int {
+	return len(ds.targets())
+}
+
+func (ds *DataSet[T]) TargetNames() []string {
+	names := make([]string, 0, ds.TargetCount())
+	for _, j := range ds.targets() {
+		names = append(names, ds.headers[j].name)
+	}
+	return names
+}
+
+/*
+View whose only target is the k-th one. The other targets are dropped, so that the features stay the same for every k.
+The view does not share its headers with ds
+*/
+func (ds *DataSet[T]) ForTarget(k int) (*DataSet[T], error) {
+	targets := ds.targets()
+	if k < 0 || k >= len(targets) {
+		return nil, fmt.Errorf("DataSet.ForTarget : target %d out of range", k)
+	}
+
+	new_ds := *ds
+	new_ds.headers = slices.Clone(ds.headers)
+	for _, j := range targets {
+		if j != targets[k] {
+			new_ds.headers[j].used = false
+		}
+	}
+
+	new_ds.trg_col_idx = uint32(targets[k])
+	new_ds.trg_cols = nil
+	new_ds.real_feat_indices = make([]int, len(ds.headers)-1)
+	new_ds.update_feat_indices()
+
+	return &new_ds, nil
+}
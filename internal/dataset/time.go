@@ -0,0 +1,79 @@
+package dataset
+
+import (
+	"fmt"
+	"time"
+)
+
+func parse_time(text string, layouts []string) (time.Time, bool) {
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+/*
+Convert the string cells of the column into time cells, trying the layouts of time.Parse in order.
+Nothing is converted if a cell matches no layout
+*/
+func (ds *DataSet[T]) ParseTimeColumn(name string, layouts ...string) error {
+	j := ds.ColumnIndex(name)
+	if j == -1 {
+		return fmt.Errorf("DataSet.ParseTimeColumn : column %q not found", name)
+	}
+
+	start, end := ds.min_bound(), ds.max_bound()
+	times := make([]DataCell, end-start)
+
+	for i := start; i < end; i++ {
+		switch c := ds.at(i, j).(type) {
+		case *StrDataCell:
+			t, ok := parse_time(c.Value, layouts)
+			if !ok {
+				return fmt.Errorf("DataSet.ParseTimeColumn : %q matches no layout", c.Value)
+			}
+			times[i-start] = &TimeDataCell{t}
+		default:
+			times[i-start] = c
+		}
+	}
+
+	for i := start; i < end; i++ {
+		ds.set_at(i, j, times[i-start])
+	}
+
+	return nil
+}
+
+/*
+Chronological views of the rows before the cutoff and of the rows at or after it.
+Rows without a time in the column belong to neither view
+*/
+func (ds *DataSet[T]) SplitByTime(column string, cutoff time.Time) (*DataSet[T], *DataSet[T], error) {
+	sorted, err := ds.SortBy([]string{column}, []bool{true})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	j := ds.ColumnIndex(column)
+	var before, after []int
+	i := 0
+
+	for s := range sorted.Samples() {
+		if c, ok := s.At(j).(*TimeDataCell); ok {
+			if c.Value.Before(cutoff) {
+				before = append(before, i)
+			} else {
+				after = append(after, i)
+			}
+		}
+		i++
+	}
+
+	train, _ := sorted.Select(before)
+	test, _ := sorted.Select(after)
+
+	return train, test, nil
+}
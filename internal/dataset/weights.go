@@ -0,0 +1,83 @@
+package dataset
+
+import (
+	"fmt"
+	"math"
+)
+
+/*
+Mark the column as holding the sample weights, which must not be negative. It stops being a feature, like a target.
+Weights scale the contribution of each row to the costs, the scaler statistics and the metrics.
+On a view, the parent dataset keeps its weights
+*/
+func (ds *DataSet[T]) SetWeightColumn(name string) error {
+	j := ds.ColumnIndex(name)
+	if j == -1 || !ds.headers[j].used {
+		return fmt.Errorf("DataSet.SetWeightColumn : column %q not found", name)
+	}
+
+	if ds.is_target(j) {
+		return fmt.Errorf("DataSet.SetWeightColumn : column %q is a target", name)
+	}
+
+	for c := range ds.ColumnAt(j) {
+		if w, ok := c.(*RealDataCell[T]); ok && (w.Value < 0 || math.IsNaN(float64(w.Value))) {
+			return fmt.Errorf("DataSet.SetWeightColumn : column %q holds the negative weight %v", name, w.Value)
+		}
+	}
+
+	ds.weight_idx = j + 1
+	ds.weight_col_name = ""
+
+	// views share real_feat_indices with their parent
+	ds.real_feat_indices = make([]int, len(ds.headers)-1)
+	ds.update_feat_indices()
+
+	return nil
+}
+
+// Unweighted rows, the weight column becoming a feature again
+func (ds *DataSet[T]) ClearWeights() {
+	if !ds.Weighted() {
+		return
+	}
+
+	ds.weight_idx = 0
+	ds.real_feat_indices = make([]int, len(ds.headers)-1)
+	ds.update_feat_indices()
+}
+
+func (ds *DataSet[T]) Weighted() bool {
+	return ds.weight_idx != 0
+}
+
+// Index of the sample weight column, -1 when rows are not weighted
+func (ds *DataSet[T]) weight_column() int {
+	return ds.weight_idx - 1
+}
+
+// Name of the sample weight column, empty when rows are not weighted
+func (ds *DataSet[T]) weight_name() string {
+	if !ds.Weighted() {
+		return ""
+	}
+	return ds.headers[ds.weight_column()].name
+}
+
+// Sum of the weights, the number of rows when not weighted
+func (ds *DataSet[T]) TotalWeight() T {
+	var total T
+	for s := range ds.Samples() {
+		total += s.GetWeight()
+	}
+	return total
+}
+
+// Weights of the rows, all 1 when not weighted
+func (ds *DataSet[T]) Weights() []T {
+	weights := make([]T, 0, ds.Size())
+	for s := range ds.Samples() {
+		weights = append(weights, s.GetWeight())
+	}
+	return weights
+}
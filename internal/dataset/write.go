@@ -0,0 +1,151 @@
+package dataset
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"golang.org/x/exp/constraints"
+)
+
+type CsvOptions struct {
+	Delim       rune   // ',' when zero
+	NA          string // text of empty cells
+	FloatFormat byte   // format of strconv.FormatFloat, used with Precision. Shortest exact representation when zero
+	Precision   int
+}
+
+// Size in bits of T, as expected by strconv
+func float_bits[T constraints.Float]() int {
+	if _, ok := any(T(0)).(float32); ok {
+		return 32
+	}
+	return 64
+}
+
+// Columns of the active view, in order
+func (ds *DataSet[T]) used_indices() []int {
+	cols := make([]int, 0, len(ds.headers))
+	for j, h := range ds.headers {
+		if h.used {
+			cols = append(cols, j)
+		}
+	}
+	return cols
+}
+
+/*
+Write the rows and columns of the active view as CSV, headers first. Times are written in time.RFC3339Nano.
+Sparse features have no column to be written in, a dataset holding some is an error : see WriteSnapshot
+*/
+func (ds *DataSet[T]) WriteCsv(w io.Writer, opts CsvOptions) error {
+	if ds.SparseFeatCount() > 0 {
+		return errors.New("DataSet.WriteCsv : sparse features can not be written, see WriteSnapshot")
+	}
+
+	writer := csv.NewWriter(w)
+	if opts.Delim != 0 {
+		writer.Comma = opts.Delim
+	}
+
+	format, prec := byte('g'), -1
+	if opts.FloatFormat != 0 {
+		format, prec = opts.FloatFormat, opts.Precision
+	}
+
+	cols := ds.used_indices()
+	record := make([]string, len(cols))
+	for k, j := range cols {
+		record[k] = ds.headers[j].name
+	}
+	if err := writer.Write(record); err != nil {
+		return err
+	}
+
+	for i := ds.min_bound(); i < ds.max_bound(); i++ {
+		for k, j := range cols {
+			switch c := ds.at(i, j).(type) {
+			case *RealDataCell[T]:
+				record[k] = strconv.FormatFloat(float64(c.Value), format, prec, float_bits[T]())
+			case *StrDataCell:
+				record[k] = c.Value
+			case *TimeDataCell:
+				record[k] = c.Value.Format(time.RFC3339Nano)
+			default:
+				record[k] = opts.NA
+			}
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+/*
+Write one JSON object per row of the active view, keyed by column name in column order.
+Empty cells, NaN and infinities are null, times are strings in time.RFC3339Nano.
+Sparse features have no key to be written under, a dataset holding some is an error : see WriteSnapshot
+*/
+func (ds *DataSet[T]) WriteJSONL(w io.Writer) error {
+	if ds.SparseFeatCount() > 0 {
+		return errors.New("DataSet.WriteJSONL : sparse features can not be written, see WriteSnapshot")
+	}
+
+	writer := bufio.NewWriter(w)
+	cols := ds.used_indices()
+
+	keys := make([][]byte, len(cols))
+	for k, j := range cols {
+		key, err := json.Marshal(ds.headers[j].name)
+		if err != nil {
+			return err
+		}
+		keys[k] = append(key, ':')
+	}
+
+	var line []byte
+	for i := ds.min_bound(); i < ds.max_bound(); i++ {
+		line = append(line[:0], '{')
+		for k, j := range cols {
+			if k > 0 {
+				line = append(line, ',')
+			}
+			line = append(line, keys[k]...)
+
+			switch c := ds.at(i, j).(type) {
+			case *RealDataCell[T]:
+				if v := float64(c.Value); math.IsNaN(v) || math.IsInf(v, 0) {
+					line = append(line, "null"...)
+				} else {
+					line = strconv.AppendFloat(line, v, 'g', -1, float_bits[T]())
+				}
+			case *StrDataCell:
+				s, err := json.Marshal(c.Value)
+				if err != nil {
+					return err
+				}
+				line = append(line, s...)
+			case *TimeDataCell:
+				line = strconv.AppendQuote(line, c.Value.Format(time.RFC3339Nano))
+			default:
+				line = append(line, "null"...)
+			}
+		}
+		line = append(line, '}', '\n')
+
+		if _, err := writer.Write(line); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
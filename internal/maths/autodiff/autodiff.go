@@ -0,0 +1,231 @@
+package autodiff
+
+import (
+	"math"
+)
+
+type node struct {
+	value    float64
+	grad     float64
+	parents  [2]int
+	partials [2]float64 // local derivatives regarding each parent
+	arity    int
+	values   []float64              // value of a Vec node, nil for scalars
+	grads    []float64              // derivatives regarding each value of a Vec node
+	backward func(t *Tape, n *node) // propagates the gradient of nodes involving vectors, used instead of partials
+}
+
+// Records every operation of a computation so that Backward can replay it in reverse.
+// A tape is not safe for concurrent use
+type Tape struct {
+	nodes []node
+}
+
+// Scalar recorded on a tape, see Vec for vectors.
+// The zero Var is the constant 0, recorded on the tape of the first variable it is combined with
+type Var struct {
+	tape *Tape
+	idx  int
+}
+
+func (t *Tape) push(n node) Var {
+	t.nodes = append(t.nodes, n)
+	return Var{t, len(t.nodes) - 1}
+}
+
+// New input of the computation
+func (t *Tape) Var(v float64) Var {
+	return t.push(node{value: v})
+}
+
+func (t *Tape) Vars(values []float64) []Var {
+	vars := make([]Var, len(values))
+	for i, v := range values {
+		vars[i] = t.Var(v)
+	}
+	return vars
+}
+
+func (t *Tape) Const(v float64) Var {
+	return t.Var(v)
+}
+
+// Record the constant 0 of a zero Var on t, or on a tape of its own when t is nil
+func (v Var) lift(t *Tape) Var {
+	if v.tape != nil {
+		return v
+	}
+
+	if t == nil {
+		t = &Tape{}
+	}
+	return t.Const(0)
+}
+
+func (v Var) unary(value, partial float64) Var {
+	v = v.lift(nil)
+	return v.tape.push(node{
+		value:    value,
+		parents:  [2]int{v.idx},
+		partials: [2]float64{partial},
+		arity:    1,
+	})
+}
+
+func (v Var) binary(w Var, value, dv, dw float64) Var {
+	v = v.lift(w.tape)
+	w = w.lift(v.tape)
+	return v.tape.push(node{
+		value:    value,
+		parents:  [2]int{v.idx, w.idx},
+		partials: [2]float64{dv, dw},
+		arity:    2,
+	})
+}
+
+func (v Var) Value() float64 {
+	if v.tape == nil {
+		return 0
+	}
+	return v.tape.nodes[v.idx].value
+}
+
+// Derivative of the last Backward output regarding v
+func (v Var) Grad() float64 {
+	if v.tape == nil {
+		return 0
+	}
+	return v.tape.nodes[v.idx].grad
+}
+
+func (v Var) Add(w Var) Var {
+	return v.binary(w, v.Value()+w.Value(), 1, 1)
+}
+
+func (v Var) Sub(w Var) Var {
+	return v.binary(w, v.Value()-w.Value(), 1, -1)
+}
+
+func (v Var) Mul(w Var) Var {
+	return v.binary(w, v.Value()*w.Value(), w.Value(), v.Value())
+}
+
+func (v Var) Div(w Var) Var {
+	a, b := v.Value(), w.Value()
+	return v.binary(w, a/b, 1/b, -a/(b*b))
+}
+
+func (v Var) Neg() Var {
+	return v.unary(-v.Value(), -1)
+}
+
+func (v Var) Scale(c float64) Var {
+	return v.unary(c*v.Value(), c)
+}
+
+func (v Var) Exp() Var {
+	e := math.Exp(v.Value())
+	return v.unary(e, e)
+}
+
+func (v Var) Log() Var {
+	return v.unary(math.Log(v.Value()), 1/v.Value())
+}
+
+// v^w. The derivative regarding w is only defined for v > 0
+func (v Var) Pow(w Var) Var {
+	a, b := v.Value(), w.Value()
+	p := math.Pow(a, b)
+
+	dw := 0.0
+	if a > 0 {
+		dw = p * math.Log(a)
+	}
+	return v.binary(w, p, b*math.Pow(a, b-1), dw)
+}
+
+func (v Var) PowConst(e float64) Var {
+	return v.unary(math.Pow(v.Value(), e), e*math.Pow(v.Value(), e-1))
+}
+
+func (v Var) Sigmoid() Var {
+	s := 1 / (1 + math.Exp(-v.Value()))
+	return v.unary(s, s*(1-s))
+}
+
+func (v Var) Tanh() Var {
+	t := math.Tanh(v.Value())
+	return v.unary(t, 1-t*t)
+}
+
+func (v Var) ReLU() Var {
+	if v.Value() > 0 {
+		return v.unary(v.Value(), 1)
+	}
+	return v.unary(0, 0)
+}
+
+// Sum of the variables. The zero Var for an empty list
+func Sum(vars ...Var) Var {
+	if len(vars) == 0 {
+		return Var{}
+	}
+
+	acc := vars[0]
+	for _, v := range vars[1:] {
+		acc = acc.Add(v)
+	}
+	return acc
+}
+
+// Dot product of two vectors of the same length. The zero Var for empty vectors
+func Dot(v, w []Var) Var {
+	if len(v) != len(w) {
+		panic("autodiff.Dot : vectors do not have the same length")
+	}
+
+	if len(v) == 0 {
+		return Var{}
+	}
+
+	acc := v[0].Mul(w[0])
+	for i := 1; i < len(v); i++ {
+		acc = acc.Add(v[i].Mul(w[i]))
+	}
+	return acc
+}
+
+// Propagate the derivatives of out to every variable it depends on
+func (t *Tape) Backward(out Var) {
+	for i := range t.nodes {
+		t.nodes[i].grad = 0
+		clear(t.nodes[i].grads)
+	}
+
+	// a constant recorded elsewhere, like the zero Var, does not depend on the variables of t
+	if out.tape != t {
+		return
+	}
+
+	t.nodes[out.idx].grad = 1
+	for i := out.idx; i >= 0; i-- {
+		n := &t.nodes[i]
+		if n.values != nil {
+			n.backward(t, n)
+			continue
+		}
+
+		if n.grad == 0 {
+			continue
+		}
+
+		if n.backward != nil {
+			n.backward(t, n)
+			continue
+		}
+
+		for k := range n.arity {
+			t.nodes[n.parents[k]].grad += n.grad * n.partials[k]
+		}
+	}
+}
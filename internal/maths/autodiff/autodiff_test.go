@@ -0,0 +1,182 @@
+package autodiff_test
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"github.com/bleak-and-bare/machine_learning/internal/maths"
+	"github.com/bleak-and-bare/machine_learning/internal/maths/autodiff"
+	"github.com/bleak-and-bare/machine_learning/internal/maths/optimization"
+	"github.com/bleak-and-bare/machine_learning/internal/maths/optimization/optimtest"
+)
+
+func TestTape_Backward(t *testing.T) {
+	f := func(tape *autodiff.Tape, x, y autodiff.Var) autodiff.Var {
+		// x*y + exp(x) / y - tanh(y)^2 + relu(x - y) + log(sigmoid(x)) + y^x
+		return autodiff.Sum(
+			x.Mul(y),
+			x.Exp().Div(y),
+			y.Tanh().PowConst(2).Neg(),
+			x.Sub(y).ReLU(),
+			x.Sigmoid().Log(),
+			y.Pow(x),
+		)
+	}
+
+	eval := func(x, y float64) float64 {
+		var tape autodiff.Tape
+		return f(&tape, tape.Var(x), tape.Var(y)).Value()
+	}
+
+	const h = 1e-6
+	for _, p := range [][2]float64{{0.3, 1.7}, {2.1, 0.8}, {-0.5, 2.5}} {
+		var tape autodiff.Tape
+		x, y := tape.Var(p[0]), tape.Var(p[1])
+		tape.Backward(f(&tape, x, y))
+
+		dx := (eval(p[0]+h, p[1]) - eval(p[0]-h, p[1])) / (2 * h)
+		dy := (eval(p[0], p[1]+h) - eval(p[0], p[1]-h)) / (2 * h)
+
+		if math.Abs(x.Grad()-dx) > 1e-5 || math.Abs(y.Grad()-dy) > 1e-5 {
+			t.Errorf("Wrong gradient at %v : [%.6f, %.6f]. Expected : [%.6f, %.6f]", p, x.Grad(), y.Grad(), dx, dy)
+		}
+	}
+}
+
+func TestHypothesis_Fit(t *testing.T) {
+	ds := dataset.NewDataSet[float64](1)
+	str := strings.NewReader(`x,y
+0,2
+0.5,3.2974425414002564
+1,5.43656365691809
+1.5,8.963378140676129
+2,14.7781121978613`)
+
+	ds.LoadCsvReader(str, ',')
+
+	// y = a * exp(b*x)
+	h := autodiff.NewHypothesis[float64](func(p, x []autodiff.Var) autodiff.Var {
+		return p[0].Mul(p[1].Mul(x[0]).Exp())
+	})
+
+	optimtest.AssertGradient(t, h, &ds, 1e-6)
+
+	o := optimization.NewLBFGS[float64](maths.DefThreshold())
+	o.UseLoss(h, optimization.SquaredErr[float64]())
+
+	if err := o.Fit(&ds); err != nil {
+		t.Errorf("LBFGS.Fit should not error : %v", err)
+		t.FailNow()
+	}
+
+	theta := o.GetParams()
+	if math.Abs(theta[0]-2) > 1e-3 || math.Abs(theta[1]-1) > 1e-3 {
+		t.Errorf("Wrong parameter values : [%.3f, %.3f] != [2, 1]", theta[0], theta[1])
+	}
+}
+
+func TestDot_Empty(t *testing.T) {
+	var tape autodiff.Tape
+	x := tape.Var(3)
+
+	zero := autodiff.Dot(nil, nil)
+	if zero.Value() != 0 {
+		t.Errorf("Empty dot product should be 0, got %v", zero.Value())
+	}
+
+	out := zero.Add(x.Mul(x))
+	tape.Backward(out)
+	if out.Value() != 9 || x.Grad() != 6 {
+		t.Errorf("Wrong value or gradient after adding an empty dot product : %v, %v", out.Value(), x.Grad())
+	}
+}
+
+// Gradients are cached by row, rows of another dataset with the same index must not reuse them
+func TestHypothesis_Cache(t *testing.T) {
+	load := func(csv string) *dataset.DataSet[float64] {
+		ds := dataset.NewDataSet[float64](1)
+		ds.LoadCsvReader(strings.NewReader(csv), ',')
+		return &ds
+	}
+
+	h := autodiff.NewHypothesis[float64](func(p, x []autodiff.Var) autodiff.Var {
+		return p[0].Mul(x[0]).Exp()
+	})
+	params := []float64{0.5}
+
+	for _, ds := range []*dataset.DataSet[float64]{load("x,y\n1,0\n"), load("x,y\n2,0\n")} {
+		for s := range ds.Samples() {
+			x := *s.GetFeat(0)
+			d, err := h.Diff(0, params, &s)
+			if err != nil {
+				t.Errorf("Hypothesis.Diff should not error : %v", err)
+			}
+
+			if expected := x * math.Exp(params[0]*x); math.Abs(d-expected) > 1e-12 {
+				t.Errorf("Wrong derivative at x = %v : %v. Expected : %v", x, d, expected)
+			}
+		}
+	}
+}
+
+func TestVec_Backward(t *testing.T) {
+	f := func(tape *autodiff.Tape, x autodiff.Vec, y autodiff.Var) autodiff.Var {
+		// sum(exp(x) * sigmoid(x) / (x^2 + 1)) + tanh(y*x).relu(x - 1) + log(x*w).x + (x[0] - x[1])*y
+		w := tape.Vec([]float64{0.5, -1, 2})
+		return autodiff.Sum(
+			x.Exp().Mul(x.Sigmoid()).Div(x.PowConst(2).Add(tape.Vec([]float64{1, 1, 1}))).Sum(),
+			x.MulVar(y).Tanh().Dot(x.Sub(tape.Vec([]float64{1, 1, 1})).ReLU()),
+			x.Mul(w).Log().Dot(x.Neg().Scale(-1)),
+			x.At(0).Sub(x.At(1)).Mul(y),
+		)
+	}
+
+	eval := func(x []float64, y float64) float64 {
+		var tape autodiff.Tape
+		return f(&tape, tape.Vec(x), tape.Var(y)).Value()
+	}
+
+	const h = 1e-6
+	x0, y0 := []float64{0.3, -1.7, 2.1}, 0.8
+
+	var tape autodiff.Tape
+	x, y := tape.Vec(x0), tape.Var(y0)
+	out := f(&tape, x, y)
+	if v := out.Value(); math.Abs(v-eval(x0, y0)) > 1e-12 {
+		t.Errorf("Wrong value : %v", v)
+	}
+	tape.Backward(out)
+
+	for k := range x0 {
+		plus, minus := slices.Clone(x0), slices.Clone(x0)
+		plus[k] += h
+		minus[k] -= h
+
+		if dx := (eval(plus, y0) - eval(minus, y0)) / (2 * h); math.Abs(x.Grad()[k]-dx) > 1e-5 {
+			t.Errorf("Wrong derivative regarding x[%d] : %.6f. Expected : %.6f", k, x.Grad()[k], dx)
+		}
+	}
+
+	if dy := (eval(x0, y0+h) - eval(x0, y0-h)) / (2 * h); math.Abs(y.Grad()-dy) > 1e-5 {
+		t.Errorf("Wrong derivative regarding y : %.6f. Expected : %.6f", y.Grad(), dy)
+	}
+}
+
+// Vectors of scalar parameters give the linear hypothesis its exact gradient
+func TestStack_Hypothesis(t *testing.T) {
+	ds := dataset.NewDataSet[float64](2)
+	ds.LoadCsvReader(strings.NewReader("a,b,y\n1,2,0\n-0.5,3,0\n"), ',')
+
+	h := autodiff.NewHypothesis[float64](func(p, x []autodiff.Var) autodiff.Var {
+		return p[0].Add(autodiff.Stack(p[1:]...).Dot(autodiff.Stack(x...)).Sigmoid())
+	})
+
+	optimtest.AssertGradient(t, h, &ds, 1e-6)
+
+	if zero := autodiff.Stack(); zero.Len() != 0 || zero.Sum().Value() != 0 {
+		t.Error("Stacking no variable should give an empty vector")
+	}
+}
@@ -0,0 +1,119 @@
+package autodiff
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"golang.org/x/exp/constraints"
+)
+
+// Hypothesis written as an expression of the parameters and of the features of one sample.
+// It implements optimization.Hypothesis with exact gradients.
+// The gradient of each sample is cached for the last parameters, so that Diff only runs one backward pass per sample
+type Hypothesis[T constraints.Float] struct {
+	Expr   func(params []Var, x []Var) Var
+	mu     sync.Mutex
+	params []T               // parameters the cache was computed for
+	cache  map[int]grad_t[T] // gradients by row
+}
+
+type grad_t[T constraints.Float] struct {
+	x    []T // features the gradient was computed for, since rows of different datasets share their index
+	grad []T
+}
+
+func NewHypothesis[T constraints.Float](expr func(params []Var, x []Var) Var) *Hypothesis[T] {
+	return &Hypothesis[T]{Expr: expr}
+}
+
+func (h *Hypothesis[T]) eval(params []T, sample *dataset.DataSample[T]) (*Tape, []Var, Var, error) {
+	x, err := sample.GetSampleTest()
+	if err != nil {
+		return nil, nil, Var{}, err
+	}
+
+	var tape Tape
+	p := make([]Var, len(params))
+	for i := range params {
+		p[i] = tape.Var(float64(params[i]))
+	}
+
+	xs := make([]Var, len(x))
+	for i := range x {
+		xs[i] = tape.Const(float64(x[i]))
+	}
+
+	out := h.Expr(p, xs)
+	if v := out.Value(); math.IsNaN(v) {
+		return nil, nil, Var{}, fmt.Errorf("autodiff.Hypothesis : expression is NaN at row %d", sample.GetRow())
+	}
+
+	return &tape, p, out, nil
+}
+
+func (h *Hypothesis[T]) On(params []T, sample *dataset.DataSample[T]) (T, error) {
+	_, _, out, err := h.eval(params, sample)
+	if err != nil {
+		return 0.0, err
+	}
+	return T(out.Value()), nil
+}
+
+func (h *Hypothesis[T]) Diff(j int, params []T, sample *dataset.DataSample[T]) (T, error) {
+	if j < 0 || j >= len(params) {
+		return 0.0, fmt.Errorf("autodiff.Hypothesis : no parameter %d", j)
+	}
+
+	grad, err := h.cached_gradient(params, sample)
+	if err != nil {
+		return 0.0, err
+	}
+	return grad[j], nil
+}
+
+// Derivatives regarding every parameter in a single backward pass
+func (h *Hypothesis[T]) Gradient(params []T, sample *dataset.DataSample[T]) ([]T, error) {
+	grad, err := h.cached_gradient(params, sample)
+	return slices.Clone(grad), err
+}
+
+func (h *Hypothesis[T]) cached_gradient(params []T, sample *dataset.DataSample[T]) ([]T, error) {
+	x, err := sample.GetSampleTest()
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	if !slices.Equal(h.params, params) {
+		h.params = slices.Clone(params)
+		h.cache = make(map[int]grad_t[T])
+	}
+	entry, ok := h.cache[sample.GetRow()]
+	h.mu.Unlock()
+
+	if ok && slices.Equal(entry.x, x) {
+		return entry.grad, nil
+	}
+
+	tape, p, out, err := h.eval(params, sample)
+	if err != nil {
+		return nil, err
+	}
+
+	tape.Backward(out)
+	grad := make([]T, len(p))
+	for i := range p {
+		grad[i] = T(p[i].Grad())
+	}
+
+	h.mu.Lock()
+	if slices.Equal(h.params, params) {
+		h.cache[sample.GetRow()] = grad_t[T]{x, grad}
+	}
+	h.mu.Unlock()
+
+	return grad, nil
+}
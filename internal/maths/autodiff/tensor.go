@@ -0,0 +1,246 @@
+package autodiff
+
+import (
+	"math"
+	"slices"
+)
+
+// Vector recorded on a tape as a single node, so that elementwise operations cost one node instead of one per element.
+// Vectors are created by Tape.Vec or Stack, operations on vectors of different lengths panic
+type Vec struct {
+	tape *Tape
+	idx  int
+}
+
+func (t *Tape) push_vec(values []float64, backward func(t *Tape, n *node)) Vec {
+	t.nodes = append(t.nodes, node{
+		values:   values,
+		grads:    make([]float64, len(values)),
+		backward: backward,
+	})
+	return Vec{t, len(t.nodes) - 1}
+}
+
+// New input vector of the computation
+func (t *Tape) Vec(values []float64) Vec {
+	return t.push_vec(append(make([]float64, 0, len(values)), values...), func(*Tape, *node) {})
+}
+
+// Vector of the variables, whose gradients flow back to each of them
+func Stack(vars ...Var) Vec {
+	var t *Tape
+	for _, v := range vars {
+		if v.tape != nil {
+			t = v.tape
+			break
+		}
+	}
+	if t == nil {
+		t = &Tape{}
+	}
+
+	idx := make([]int, len(vars))
+	values := make([]float64, len(vars))
+	for k, v := range vars {
+		v = v.lift(t)
+		idx[k], values[k] = v.idx, v.Value()
+	}
+
+	return t.push_vec(values, func(t *Tape, n *node) {
+		for k, i := range idx {
+			t.nodes[i].grad += n.grads[k]
+		}
+	})
+}
+
+func (v Vec) node() *node {
+	return &v.tape.nodes[v.idx]
+}
+
+func (v Vec) Len() int {
+	return len(v.node().values)
+}
+
+func (v Vec) Value() []float64 {
+	return slices.Clone(v.node().values)
+}
+
+// Derivatives of the last Backward output regarding each value of v
+func (v Vec) Grad() []float64 {
+	return slices.Clone(v.node().grads)
+}
+
+func (v Vec) check_len(w Vec, op string) {
+	if v.Len() != w.Len() {
+		panic("autodiff.Vec." + op + " : vectors do not have the same length")
+	}
+}
+
+// Elementwise f, which returns its value and its derivative
+func (v Vec) unary(f func(a float64) (value, partial float64)) Vec {
+	src := v.node().values
+	values := make([]float64, len(src))
+	partials := make([]float64, len(src))
+	for k, a := range src {
+		values[k], partials[k] = f(a)
+	}
+
+	return v.tape.push_vec(values, func(t *Tape, n *node) {
+		g := t.nodes[v.idx].grads
+		for k, d := range partials {
+			g[k] += n.grads[k] * d
+		}
+	})
+}
+
+// Elementwise f, which returns its value and its derivatives regarding each operand
+func (v Vec) binary(w Vec, op string, f func(a, b float64) (value, dv, dw float64)) Vec {
+	v.check_len(w, op)
+
+	a, b := v.node().values, w.node().values
+	values := make([]float64, len(a))
+	dv, dw := make([]float64, len(a)), make([]float64, len(a))
+	for k := range a {
+		values[k], dv[k], dw[k] = f(a[k], b[k])
+	}
+
+	return v.tape.push_vec(values, func(t *Tape, n *node) {
+		gv, gw := t.nodes[v.idx].grads, t.nodes[w.idx].grads
+		for k, g := range n.grads {
+			gv[k] += g * dv[k]
+			gw[k] += g * dw[k]
+		}
+	})
+}
+
+func (v Vec) Add(w Vec) Vec {
+	return v.binary(w, "Add", func(a, b float64) (float64, float64, float64) { return a + b, 1, 1 })
+}
+
+func (v Vec) Sub(w Vec) Vec {
+	return v.binary(w, "Sub", func(a, b float64) (float64, float64, float64) { return a - b, 1, -1 })
+}
+
+// Elementwise product
+func (v Vec) Mul(w Vec) Vec {
+	return v.binary(w, "Mul", func(a, b float64) (float64, float64, float64) { return a * b, b, a })
+}
+
+// Elementwise quotient
+func (v Vec) Div(w Vec) Vec {
+	return v.binary(w, "Div", func(a, b float64) (float64, float64, float64) { return a / b, 1 / b, -a / (b * b) })
+}
+
+func (v Vec) Neg() Vec {
+	return v.Scale(-1)
+}
+
+func (v Vec) Scale(c float64) Vec {
+	return v.unary(func(a float64) (float64, float64) { return c * a, c })
+}
+
+// Every value multiplied by the scalar s
+func (v Vec) MulVar(s Var) Vec {
+	s = s.lift(v.tape)
+	src, c := v.node().values, s.Value()
+	values := make([]float64, len(src))
+	for k, a := range src {
+		values[k] = c * a
+	}
+
+	return v.tape.push_vec(values, func(t *Tape, n *node) {
+		g := t.nodes[v.idx].grads
+		for k, d := range n.grads {
+			g[k] += d * c
+			t.nodes[s.idx].grad += d * src[k]
+		}
+	})
+}
+
+func (v Vec) Exp() Vec {
+	return v.unary(func(a float64) (float64, float64) {
+		e := math.Exp(a)
+		return e, e
+	})
+}
+
+func (v Vec) Log() Vec {
+	return v.unary(func(a float64) (float64, float64) { return math.Log(a), 1 / a })
+}
+
+func (v Vec) PowConst(e float64) Vec {
+	return v.unary(func(a float64) (float64, float64) { return math.Pow(a, e), e * math.Pow(a, e-1) })
+}
+
+func (v Vec) Sigmoid() Vec {
+	return v.unary(func(a float64) (float64, float64) {
+		s := 1 / (1 + math.Exp(-a))
+		return s, s * (1 - s)
+	})
+}
+
+func (v Vec) Tanh() Vec {
+	return v.unary(func(a float64) (float64, float64) {
+		th := math.Tanh(a)
+		return th, 1 - th*th
+	})
+}
+
+func (v Vec) ReLU() Vec {
+	return v.unary(func(a float64) (float64, float64) {
+		if a > 0 {
+			return a, 1
+		}
+		return 0, 0
+	})
+}
+
+// i-th value of v
+func (v Vec) At(i int) Var {
+	return v.tape.push(node{
+		value: v.node().values[i],
+		backward: func(t *Tape, n *node) {
+			t.nodes[v.idx].grads[i] += n.grad
+		},
+	})
+}
+
+// Sum of the values of v, 0 for an empty vector
+func (v Vec) Sum() Var {
+	var sum float64
+	for _, a := range v.node().values {
+		sum += a
+	}
+
+	return v.tape.push(node{
+		value: sum,
+		backward: func(t *Tape, n *node) {
+			g := t.nodes[v.idx].grads
+			for k := range g {
+				g[k] += n.grad
+			}
+		},
+	})
+}
+
+// Dot product of two vectors of the same length
+func (v Vec) Dot(w Vec) Var {
+	v.check_len(w, "Dot")
+
+	a, b := v.node().values, w.node().values
+	var dot float64
+	for k := range a {
+		dot += a[k] * b[k]
+	}
+
+	return v.tape.push(node{
+		value: dot,
+		backward: func(t *Tape, n *node) {
+			gv, gw := t.nodes[v.idx].grads, t.nodes[w.idx].grads
+			for k := range a {
+				gv[k] += n.grad * b[k]
+				gw[k] += n.grad * a[k]
+			}
+		},
+	})
+}
@@ -1 +1,251 @@
 package matrix
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Dense row major matrix
+type Dense[T constraints.Float] struct {
+	rows int
+	cols int
+	data []T
+}
+
+func New[T constraints.Float](rows, cols int) Dense[T] {
+	return Dense[T]{
+		rows: rows,
+		cols: cols,
+		data: make([]T, rows*cols),
+	}
+}
+
+func Identity[T constraints.Float](n int) Dense[T] {
+	m := New[T](n, n)
+	for i := range n {
+		m.Set(i, i, 1)
+	}
+	return m
+}
+
+// Build a matrix from its rows. Every row must have the same length
+func FromRows[T constraints.Float](rows [][]T) (Dense[T], error) {
+	if len(rows) == 0 {
+		return Dense[T]{}, nil
+	}
+
+	m := New[T](len(rows), len(rows[0]))
+	for i, r := range rows {
+		if len(r) != m.cols {
+			return Dense[T]{}, fmt.Errorf("matrix.FromRows : row %d has %d columns instead of %d", i, len(r), m.cols)
+		}
+		copy(m.data[i*m.cols:], r)
+	}
+
+	return m, nil
+}
+
+func (m *Dense[T]) Rows() int {
+	return m.rows
+}
+
+func (m *Dense[T]) Cols() int {
+	return m.cols
+}
+
+func (m *Dense[T]) At(i, j int) T {
+	return m.data[i*m.cols+j]
+}
+
+func (m *Dense[T]) Set(i, j int, v T) {
+	m.data[i*m.cols+j] = v
+}
+
+func (m *Dense[T]) Add(i, j int, v T) {
+	m.data[i*m.cols+j] += v
+}
+
+// Returns the i-th row, sharing memory with the matrix
+func (m *Dense[T]) Row(i int) []T {
+	return m.data[i*m.cols : (i+1)*m.cols]
+}
+
+func (m *Dense[T]) Clone() Dense[T] {
+	c := *m
+	c.data = append([]T(nil), m.data...)
+	return c
+}
+
+func (m *Dense[T]) Transpose() Dense[T] {
+	t := New[T](m.cols, m.rows)
+	for i := range m.rows {
+		for j := range m.cols {
+			t.Set(j, i, m.At(i, j))
+		}
+	}
+	return t
+}
+
+func (m *Dense[T]) Mul(o *Dense[T]) (Dense[T], error) {
+	if m.cols != o.rows {
+		return Dense[T]{}, fmt.Errorf("matrix.Mul : shape mismatch %dx%d * %dx%d", m.rows, m.cols, o.rows, o.cols)
+	}
+
+	r := New[T](m.rows, o.cols)
+	for i := range m.rows {
+		for k := range m.cols {
+			a := m.At(i, k)
+			if a == 0 {
+				continue
+			}
+			for j := range o.cols {
+				r.data[i*r.cols+j] += a * o.At(k, j)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+func (m *Dense[T]) MulVec(v []T) ([]T, error) {
+	if m.cols != len(v) {
+		return nil, fmt.Errorf("matrix.MulVec : shape mismatch %dx%d * %d", m.rows, m.cols, len(v))
+	}
+
+	r := make([]T, m.rows)
+	for i := range m.rows {
+		for j, x := range m.Row(i) {
+			r[i] += x * v[j]
+		}
+	}
+
+	return r, nil
+}
+
+// Solve m * x = b with a LU decomposition with partial pivoting
+func (m *Dense[T]) Solve(b []T) ([]T, error) {
+	if m.rows != m.cols {
+		return nil, errors.New("matrix.Solve : matrix is not square")
+	}
+
+	if len(b) != m.rows {
+		return nil, errors.New("matrix.Solve : right hand side does not match matrix size")
+	}
+
+	n := m.rows
+	a := m.Clone()
+	x := append([]T(nil), b...)
+
+	var scale float64
+	for _, v := range a.data {
+		scale = max(scale, math.Abs(float64(v)))
+	}
+
+	for k := range n {
+		pivot := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(float64(a.At(i, k))) > math.Abs(float64(a.At(pivot, k))) {
+				pivot = i
+			}
+		}
+
+		if math.Abs(float64(a.At(pivot, k))) <= 1e-12*max(scale, 1) {
+			return nil, errors.New("matrix.Solve : matrix is singular")
+		}
+
+		if pivot != k {
+			for j := range n {
+				a.data[k*n+j], a.data[pivot*n+j] = a.data[pivot*n+j], a.data[k*n+j]
+			}
+			x[k], x[pivot] = x[pivot], x[k]
+		}
+
+		for i := k + 1; i < n; i++ {
+			f := a.At(i, k) / a.At(k, k)
+			if f == 0 {
+				continue
+			}
+			for j := k; j < n; j++ {
+				a.data[i*n+j] -= f * a.At(k, j)
+			}
+			x[i] -= f * x[k]
+		}
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		sum := x[i]
+		for j := i + 1; j < n; j++ {
+			sum -= a.At(i, j) * x[j]
+		}
+		x[i] = sum / a.At(i, i)
+	}
+
+	return x, nil
+}
+
+// Eigen decomposition of a symmetric matrix with the cyclic Jacobi method.
+// Returns the eigenvalues and the matrix whose columns are the matching eigenvectors
+func (m *Dense[T]) SymEigen() ([]T, Dense[T], error) {
+	if m.rows != m.cols {
+		return nil, Dense[T]{}, errors.New("matrix.SymEigen : matrix is not square")
+	}
+
+	n := m.rows
+	a := m.Clone()
+	v := Identity[T](n)
+
+	for range 100 {
+		var off float64
+		for i := range n {
+			for j := i + 1; j < n; j++ {
+				off += float64(a.At(i, j) * a.At(i, j))
+			}
+		}
+
+		if off <= 1e-30 {
+			break
+		}
+
+		for p := range n {
+			for q := p + 1; q < n; q++ {
+				apq := float64(a.At(p, q))
+				if apq == 0 {
+					continue
+				}
+
+				theta := float64(a.At(q, q)-a.At(p, p)) / (2 * apq)
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+
+				for k := range n {
+					akp, akq := float64(a.At(k, p)), float64(a.At(k, q))
+					a.Set(k, p, T(c*akp-s*akq))
+					a.Set(k, q, T(s*akp+c*akq))
+				}
+
+				for k := range n {
+					apk, aqk := float64(a.At(p, k)), float64(a.At(q, k))
+					a.Set(p, k, T(c*apk-s*aqk))
+					a.Set(q, k, T(s*apk+c*aqk))
+				}
+
+				for k := range n {
+					vkp, vkq := float64(v.At(k, p)), float64(v.At(k, q))
+					v.Set(k, p, T(c*vkp-s*vkq))
+					v.Set(k, q, T(s*vkp+c*vkq))
+				}
+			}
+		}
+	}
+
+	values := make([]T, n)
+	for i := range n {
+		values[i] = a.At(i, i)
+	}
+
+	return values, v, nil
+}
@@ -0,0 +1,62 @@
+package matrix
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDense_Solve(t *testing.T) {
+	m, _ := FromRows([][]float64{
+		{0, 2, 1},
+		{1, 1, 0},
+		{3, 0, 1},
+	})
+
+	x, err := m.Solve([]float64{5, 3, 6})
+	if err != nil {
+		t.Errorf("Dense.Solve should not error : %v", err)
+		t.FailNow()
+	}
+
+	b, _ := m.MulVec(x)
+	for i, v := range []float64{5, 3, 6} {
+		if math.Abs(b[i]-v) > 1e-9 {
+			t.Errorf("Wrong solution : m * %v = %v", x, b)
+			break
+		}
+	}
+
+	singular, _ := FromRows([][]float64{{1, 2}, {2, 4}})
+	if _, err := singular.Solve([]float64{1, 1}); err == nil {
+		t.Error("Solving a singular system should error")
+	}
+}
+
+func TestDense_SymEigen(t *testing.T) {
+	m, _ := FromRows([][]float64{
+		{4, 1, 2},
+		{1, 3, 0},
+		{2, 0, 5},
+	})
+
+	values, vectors, err := m.SymEigen()
+	if err != nil {
+		t.Errorf("Dense.SymEigen should not error : %v", err)
+		t.FailNow()
+	}
+
+	for k, lambda := range values {
+		v := make([]float64, 3)
+		for i := range v {
+			v[i] = vectors.At(i, k)
+		}
+
+		mv, _ := m.MulVec(v)
+		for i := range v {
+			if math.Abs(mv[i]-lambda*v[i]) > 1e-9 {
+				t.Errorf("Column %d is not an eigenvector of eigenvalue %.3f", k, lambda)
+				break
+			}
+		}
+	}
+}
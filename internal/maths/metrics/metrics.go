@@ -32,3 +32,30 @@ func MAE[T Number](trg, pred iter.Seq[T]) float64 {
 		return T(math.Abs(float64(v)))
 	})))
 }
+
+func weighted_mean[T Number](v, weights iter.Seq[T]) T {
+	var sum, total T
+	for v, w := range vector.Zip(v, weights) {
+		sum += w * v
+		total += w
+	}
+	return sum / total
+}
+
+// R2 score where each target counts for its weight
+func WeightedR2Score[T Number](trg, pred, weights iter.Seq[T]) float64 {
+	trg_var, _, _ := maths.WeightedWelfordVar(vector.Zip(trg, weights))
+	return float64(1.0 - weighted_mean(adapter.Squared(vector.Diff(trg, pred)), weights)/trg_var)
+}
+
+// Weighted root mean squared error
+func WeightedRMSE[T Number](trg, pred, weights iter.Seq[T]) float64 {
+	return math.Sqrt(float64(weighted_mean(adapter.Squared(vector.Diff(trg, pred)), weights)))
+}
+
+// Weighted mean absolute error
+func WeightedMAE[T Number](trg, pred, weights iter.Seq[T]) float64 {
+	return float64(weighted_mean(iterable.Map(vector.Diff(trg, pred), func(v T) T {
+		return T(math.Abs(float64(v)))
+	}), weights))
+}
@@ -0,0 +1,110 @@
+package optimization
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"github.com/bleak-and-bare/machine_learning/internal/maths"
+	"golang.org/x/exp/constraints"
+)
+
+// Simulated annealing with gaussian moves and a geometric cooling schedule.
+// One epoch runs MovesPerEpoch proposals at a fixed temperature
+type SimulatedAnnealing[T constraints.Float] struct {
+	theta         []T
+	history       []HistoryEntry[T]
+	Temperature   float64 // initial temperature. 0 uses the absolute cost of the starting point
+	Cooling       float64 // temperature factor applied after each epoch
+	Step          float64 // standard deviation of the moves relative to max(1, |theta_j|)
+	MovesPerEpoch int     // 0 uses 10 moves per parameter
+	Seed          uint64  // 0 picks a random one
+	Bounds        Bounds[T]
+	Threshold     maths.Threshold // stops when the relative change of the current cost over an epoch is below CostEps
+	Cost          func(theta []T, ds *dataset.DataSet[T]) T
+}
+
+func NewSimulatedAnnealing[T constraints.Float](t maths.Threshold) SimulatedAnnealing[T] {
+	return SimulatedAnnealing[T]{
+		Cooling:   0.95,
+		Step:      0.1,
+		Threshold: t,
+	}
+}
+
+func (o *SimulatedAnnealing[T]) GetParams() []T {
+	return o.theta
+}
+
+func (o *SimulatedAnnealing[T]) GetHistory() []HistoryEntry[T] {
+	return o.history
+}
+
+func (o *SimulatedAnnealing[T]) Fit(ds *dataset.DataSet[T]) error {
+	if o.Cost == nil {
+		return errors.New("No cost function supplied")
+	}
+
+	o.theta = initial_params(ds)
+	o.history = nil
+	n := len(o.theta)
+
+	if err := o.Bounds.validate(n); err != nil {
+		return err
+	}
+
+	rng := rand.New(new_rng(o.Seed))
+	f := objective[T]{cost: o.Cost, ds: ds}
+
+	x := to_f64(o.theta)
+	o.Bounds.clip(x)
+	fx := f.value(x)
+	best, f_best := append([]float64(nil), x...), fx
+
+	temp := o.Temperature
+	if temp <= 0 {
+		temp = max(math.Abs(fx), 1e-3)
+	}
+
+	moves := o.MovesPerEpoch
+	if moves <= 0 {
+		moves = 10 * n
+	}
+
+	for epoch := 0; epoch < o.Threshold.MaxEpochs; epoch++ {
+		start := fx
+
+		for range moves {
+			// move a single random coordinate, which keeps the acceptance rate usable in high dimension
+			j := rng.IntN(n)
+			cand := append([]float64(nil), x...)
+			cand[j] += rng.NormFloat64() * o.Step * max(1, math.Abs(x[j]))
+			o.Bounds.clip(cand)
+
+			f_cand := f.value(cand)
+			if math.IsNaN(f_cand) {
+				continue
+			}
+
+			if f_cand <= fx || rng.Float64() < math.Exp((fx-f_cand)/temp) {
+				x, fx = cand, f_cand
+				if fx < f_best {
+					best, f_best = append([]float64(nil), x...), fx
+				}
+			}
+		}
+
+		temp *= o.Cooling
+		o.history = append(o.history, HistoryEntry[T]{epoch + 1, T(f_best), 0})
+
+		if epoch >= o.Threshold.MinEphocs && math.Abs(fx-start)/max(1, math.Abs(start)) <= float64(o.Threshold.CostEps) {
+			fmt.Printf("Hitting cost breakpoint. Total epochs : %d\n", epoch+1)
+			break
+		}
+	}
+
+	o.theta = from_f64[T](best)
+	return nil
+}
@@ -0,0 +1,43 @@
+package optimization
+
+import (
+	"errors"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Per parameter box constraints. A nil slice leaves that side unbounded, as does an infinite value
+type Bounds[T constraints.Float] struct {
+	Lower []T
+	Upper []T
+}
+
+func (b *Bounds[T]) validate(n int) error {
+	if b.Lower != nil && len(b.Lower) != n {
+		return errors.New("Bounds : lower bounds do not match the number of parameters")
+	}
+
+	if b.Upper != nil && len(b.Upper) != n {
+		return errors.New("Bounds : upper bounds do not match the number of parameters")
+	}
+
+	for i := range n {
+		if b.Lower != nil && b.Upper != nil && b.Lower[i] > b.Upper[i] {
+			return errors.New("Bounds : lower bound above upper bound")
+		}
+	}
+
+	return nil
+}
+
+// Clip x into the box, in place
+func (b *Bounds[T]) clip(x []float64) {
+	for i := range x {
+		if b.Lower != nil {
+			x[i] = max(x[i], float64(b.Lower[i]))
+		}
+		if b.Upper != nil {
+			x[i] = min(x[i], float64(b.Upper[i]))
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package optimization
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"slices"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Serialized state of a GradientDescent run
+type checkpoint[T constraints.Float] struct {
+	Theta   []T
+	Epoch   int
+	Rng     []byte
+	History []HistoryEntry[T]
+}
+
+func (g *GradientDescent[T]) CheckpointWriter(w io.Writer) error {
+	if g.theta == nil {
+		return errors.New("GradientDescent.Checkpoint : nothing to save, model is not initialized")
+	}
+
+	c := checkpoint[T]{
+		Theta:   g.theta,
+		Epoch:   g.epoch,
+		History: g.history,
+	}
+
+	if g.rng != nil {
+		rng, err := g.rng.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		c.Rng = rng
+	}
+
+	return gob.NewEncoder(w).Encode(&c)
+}
+
+// Save parameters, epoch counter, shuffling state and history to path
+func (g *GradientDescent[T]) Checkpoint(path string) error {
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if err := g.CheckpointWriter(file); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	// never leave a truncated checkpoint behind if the run is killed while writing
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
+
+func (g *GradientDescent[T]) RestoreReader(r io.Reader) error {
+	var c checkpoint[T]
+	if err := gob.NewDecoder(r).Decode(&c); err != nil {
+		return fmt.Errorf("GradientDescent.Restore : %v", err)
+	}
+
+	g.rng = nil
+	if c.Rng != nil {
+		var rng rand.PCG
+		if err := rng.UnmarshalBinary(c.Rng); err != nil {
+			return fmt.Errorf("GradientDescent.Restore : %v", err)
+		}
+		g.rng = &rng
+	}
+
+	g.theta = c.Theta
+	g.epoch = c.Epoch
+	g.history = slices.Clip(c.History)
+	g.restored = true
+
+	return nil
+}
+
+// Load a checkpoint written by Checkpoint. The next call to Fit continues from it
+func (g *GradientDescent[T]) Restore(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return g.RestoreReader(file)
+}
@@ -0,0 +1,201 @@
+package optimization
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sort"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"github.com/bleak-and-bare/machine_learning/internal/maths"
+	"github.com/bleak-and-bare/machine_learning/internal/maths/matrix"
+	"golang.org/x/exp/constraints"
+)
+
+// Covariance Matrix Adaptation Evolution Strategy, with the default parameters of Hansen's tutorial.
+// One epoch is one generation
+type CMAES[T constraints.Float] struct {
+	theta      []T
+	history    []HistoryEntry[T]
+	Sigma      float64 // initial step size
+	Population int     // offsprings per generation. 0 uses 4 + 3*ln(n)
+	Seed       uint64  // 0 picks a random one
+	Bounds     Bounds[T]
+	Threshold  maths.Threshold // stops when the relative cost spread of the selected offsprings is below CostEps
+	Cost       func(theta []T, ds *dataset.DataSet[T]) T
+}
+
+func NewCMAES[T constraints.Float](t maths.Threshold) CMAES[T] {
+	return CMAES[T]{
+		Sigma:     0.5,
+		Threshold: t,
+	}
+}
+
+func (o *CMAES[T]) GetParams() []T {
+	return o.theta
+}
+
+func (o *CMAES[T]) GetHistory() []HistoryEntry[T] {
+	return o.history
+}
+
+func (o *CMAES[T]) Fit(ds *dataset.DataSet[T]) error {
+	if o.Cost == nil {
+		return errors.New("No cost function supplied")
+	}
+
+	o.theta = initial_params(ds)
+	o.history = nil
+	n := len(o.theta)
+	nf := float64(n)
+
+	if err := o.Bounds.validate(n); err != nil {
+		return err
+	}
+
+	rng := rand.New(new_rng(o.Seed))
+	f := objective[T]{cost: o.Cost, ds: ds}
+
+	lambda := o.Population
+	if lambda <= 0 {
+		lambda = 4 + int(3*math.Log(nf))
+	}
+	mu := max(1, lambda/2)
+
+	weights := make([]float64, mu)
+	var w_sum, w_sq float64
+	for i := range weights {
+		weights[i] = math.Log(float64(mu)+0.5) - math.Log(float64(i+1))
+		w_sum += weights[i]
+	}
+	for i := range weights {
+		weights[i] /= w_sum
+		w_sq += weights[i] * weights[i]
+	}
+	mueff := 1 / w_sq
+
+	cc := (4 + mueff/nf) / (nf + 4 + 2*mueff/nf)
+	cs := (mueff + 2) / (nf + mueff + 5)
+	c1 := 2 / ((nf+1.3)*(nf+1.3) + mueff)
+	cmu := min(1-c1, 2*(mueff-2+1/mueff)/((nf+2)*(nf+2)+mueff))
+	damps := 1 + 2*max(0, math.Sqrt((mueff-1)/(nf+1))-1) + cs
+	chi_n := math.Sqrt(nf) * (1 - 1/(4*nf) + 1/(21*nf*nf))
+
+	mean := to_f64(o.theta)
+	o.Bounds.clip(mean)
+	best, f_best := append([]float64(nil), mean...), f.value(mean)
+
+	sigma := o.Sigma
+	if sigma <= 0 {
+		sigma = 0.5
+	}
+
+	cov := matrix.Identity[float64](n)
+	pc, ps := make([]float64, n), make([]float64, n)
+
+	type offspring struct {
+		x []float64
+		y []float64
+		f float64
+	}
+
+	for gen := 0; gen < o.Threshold.MaxEpochs; gen++ {
+		eigen_values, basis, err := cov.SymEigen()
+		if err != nil {
+			return err
+		}
+
+		d := make([]float64, n)
+		for i, v := range eigen_values {
+			d[i] = math.Sqrt(max(v, 1e-20))
+		}
+
+		pop := make([]offspring, lambda)
+		for k := range pop {
+			y := make([]float64, n)
+			for i := range n {
+				z := rng.NormFloat64() * d[i]
+				for r := range n {
+					y[r] += basis.At(r, i) * z
+				}
+			}
+
+			x := add_scaled(mean, sigma, y)
+			o.Bounds.clip(x)
+			// keep the step consistent with the repaired point
+			y = scale(sub(x, mean), 1/sigma)
+			pop[k] = offspring{x, y, f.value(x)}
+		}
+
+		sort.SliceStable(pop, func(i, j int) bool {
+			return pop[i].f < pop[j].f || (!math.IsNaN(pop[i].f) && math.IsNaN(pop[j].f))
+		})
+
+		if pop[0].f < f_best {
+			best, f_best = pop[0].x, pop[0].f
+		}
+
+		y_w := make([]float64, n)
+		for i := range mu {
+			axpy(weights[i], pop[i].y, y_w)
+		}
+		mean = add_scaled(mean, sigma, y_w)
+
+		// C^-1/2 * y_w = B * D^-1 * B^T * y_w
+		inv_sqrt := make([]float64, n)
+		for i := range n {
+			var proj float64
+			for r := range n {
+				proj += basis.At(r, i) * y_w[r]
+			}
+			for r := range n {
+				inv_sqrt[r] += basis.At(r, i) * proj / d[i]
+			}
+		}
+
+		ps = add_scaled(scale(ps, 1-cs), math.Sqrt(cs*(2-cs)*mueff), inv_sqrt)
+		ps_norm := norm(ps)
+
+		hsig := 0.0
+		if ps_norm/math.Sqrt(1-math.Pow(1-cs, 2*float64(gen+1)))/chi_n < 1.4+2/(nf+1) {
+			hsig = 1
+		}
+
+		pc = add_scaled(scale(pc, 1-cc), hsig*math.Sqrt(cc*(2-cc)*mueff), y_w)
+
+		for i := range n {
+			for j := range n {
+				rank_mu := 0.0
+				for k := range mu {
+					rank_mu += weights[k] * pop[k].y[i] * pop[k].y[j]
+				}
+
+				c := cov.At(i, j)
+				c = (1-c1-cmu)*c + c1*(pc[i]*pc[j]+(1-hsig)*cc*(2-cc)*c) + cmu*rank_mu
+				cov.Set(i, j, c)
+			}
+		}
+
+		sigma *= math.Exp((cs / damps) * (ps_norm/chi_n - 1))
+		o.history = append(o.history, HistoryEntry[T]{gen + 1, T(f_best), 0})
+
+		if gen >= o.Threshold.MinEphocs {
+			spread := math.Abs(pop[mu-1].f-pop[0].f) / max(1, math.Abs(pop[0].f))
+			if spread <= float64(o.Threshold.CostEps) {
+				fmt.Printf("Hitting cost breakpoint. Total generations : %d\n", gen+1)
+				break
+			}
+		}
+
+		if sigma*slices.Max(d) < 1e-12 {
+			fmt.Printf("Step size collapsed. Total generations : %d\n", gen+1)
+			break
+		}
+	}
+
+	o.theta = from_f64[T](best)
+	return nil
+}
@@ -0,0 +1,96 @@
+package optimization
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"github.com/bleak-and-bare/machine_learning/internal/maths"
+)
+
+func mae_cost(theta []float64, ds *dataset.DataSet[float64]) float64 {
+	return Cost(theta, ds, &linear_reg_hypo[float64]{}, MAE[float64]())
+}
+
+func TestDerivativeFree(t *testing.T) {
+	ds := dataset.NewDataSet[float64](1)
+	str := strings.NewReader(`x,y
+0,1
+1,3
+2,5
+3,7
+4,9`)
+
+	ds.LoadCsvReader(str, ',')
+
+	th := maths.DefThreshold()
+	th.MaxEpochs = 2000
+	th.CostEps = 1e-10
+
+	nm := NewNelderMead[float64](th)
+	nm.Cost = mae_cost
+
+	sa := NewSimulatedAnnealing[float64](th)
+	sa.Cost = mae_cost
+	sa.Seed = 7
+
+	cma := NewCMAES[float64](th)
+	cma.Cost = mae_cost
+	cma.Seed = 7
+
+	tests := []struct {
+		name string
+		opt  interface {
+			Fit(*dataset.DataSet[float64]) error
+			GetParams() []float64
+			GetHistory() []HistoryEntry[float64]
+		}
+		tol float64
+	}{
+		{"Nelder-Mead", &nm, 1e-3},
+		{"Simulated annealing", &sa, 5e-2},
+		{"CMA-ES", &cma, 1e-3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opt.Fit(&ds); err != nil {
+				t.Errorf("Fit should not error : %v", err)
+				t.FailNow()
+			}
+
+			theta := tt.opt.GetParams()
+			if math.Abs(theta[0]-1) > tt.tol || math.Abs(theta[1]-2) > tt.tol {
+				t.Errorf("Wrong parameter values : [%.3f, %.3f] != [1, 2]", theta[0], theta[1])
+			}
+
+			if len(tt.opt.GetHistory()) == 0 {
+				t.Error("History should not be empty")
+			}
+		})
+	}
+}
+
+func TestNelderMead_Bounds(t *testing.T) {
+	ds := dataset.NewDataSet[float64](1)
+	str := strings.NewReader(`x,y
+0,1
+1,3
+2,5`)
+
+	ds.LoadCsvReader(str, ',')
+
+	nm := NewNelderMead[float64](maths.DefThreshold())
+	nm.Cost = mae_cost
+	nm.Bounds = Bounds[float64]{Upper: []float64{math.Inf(1), 1.5}}
+
+	if err := nm.Fit(&ds); err != nil {
+		t.Errorf("Fit should not error : %v", err)
+		t.FailNow()
+	}
+
+	if theta := nm.GetParams(); theta[1] > 1.5 {
+		t.Errorf("Upper bound violated : %.3f > 1.5", theta[1])
+	}
+}
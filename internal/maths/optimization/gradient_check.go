@@ -0,0 +1,152 @@
+package optimization
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"golang.org/x/exp/constraints"
+)
+
+type GradientCheckOptions struct {
+	Params int     // number of parameters. 0 uses FeatCount() + 1
+	Points int     // random parameter vectors to check. 0 uses 5
+	Rows   int     // rows checked for hypotheses, from the start of the dataset. 0 uses 100
+	Scale  float64 // standard deviation of the random parameters. 0 uses 1
+	Step   float64 // finite difference step relative to max(1, |theta_j|). 0 picks one from the precision of T
+	Seed   uint64  // 0 picks a random one
+}
+
+/*
+Result of a gradient check. The error of one comparison is |analytic - numeric| / max(1, |analytic|, |numeric|),
+which is a relative error for large derivatives and an absolute one near zero
+*/
+type GradientCheck struct {
+	MaxRelErr []float64 // worst error per parameter
+	Worst     float64   // worst error over every parameter
+}
+
+func check_options[T constraints.Float](opts GradientCheckOptions, ds *dataset.DataSet[T]) GradientCheckOptions {
+	if opts.Params <= 0 {
+		opts.Params = ds.FeatCount() + 1
+	}
+	if opts.Points <= 0 {
+		opts.Points = 5
+	}
+	if opts.Rows <= 0 {
+		opts.Rows = 100
+	}
+	if opts.Scale <= 0 {
+		opts.Scale = 1
+	}
+	if opts.Step <= 0 {
+		// cube root of the machine epsilon balances truncation and rounding errors of central differences
+		var one T = 1
+		if one+T(1e-10) == one {
+			opts.Step = 5e-3
+		} else {
+			opts.Step = 6e-6
+		}
+	}
+	return opts
+}
+
+func (c *GradientCheck) record(j int, analytic, numeric float64) {
+	err := math.Abs(analytic-numeric) / max(1, math.Abs(analytic), math.Abs(numeric))
+	if math.IsNaN(err) {
+		err = math.Inf(1)
+	}
+	c.MaxRelErr[j] = max(c.MaxRelErr[j], err)
+	c.Worst = max(c.Worst, err)
+}
+
+func random_params[T constraints.Float](r *rand.Rand, opts GradientCheckOptions) []T {
+	theta := make([]T, opts.Params)
+	for j := range theta {
+		theta[j] = T(r.NormFloat64() * opts.Scale)
+	}
+	return theta
+}
+
+// Compare h.Diff against central differences of h.On, on the first rows of ds at random parameters
+func CheckGradient[T constraints.Float](h Hypothesis[T], ds *dataset.DataSet[T], opts GradientCheckOptions) (GradientCheck, error) {
+	opts = check_options(opts, ds)
+	r := rand.New(new_rng(opts.Seed))
+	check := GradientCheck{MaxRelErr: make([]float64, opts.Params)}
+
+	if ds.Empty() {
+		return check, errors.New("CheckGradient : empty dataset")
+	}
+
+	for range opts.Points {
+		theta := random_params[T](r, opts)
+		rows := 0
+
+		for s := range ds.Samples() {
+			if rows >= opts.Rows {
+				break
+			}
+			rows++
+
+			for j := range theta {
+				analytic, err := h.Diff(j, theta, &s)
+				if err != nil {
+					return check, err
+				}
+
+				step := T(opts.Step * max(1, math.Abs(float64(theta[j]))))
+				orig := theta[j]
+
+				theta[j] = orig + step
+				up, err := h.On(theta, &s)
+				if err != nil {
+					theta[j] = orig
+					return check, err
+				}
+
+				theta[j] = orig - step
+				down, err := h.On(theta, &s)
+				theta[j] = orig
+				if err != nil {
+					return check, err
+				}
+
+				check.record(j, float64(analytic), float64(up-down)/(2*float64(step)))
+			}
+		}
+	}
+
+	return check, nil
+}
+
+// Compare partial_diff against central differences of cost over the whole dataset, at random parameters
+func CheckCostGradient[T constraints.Float](cost func(theta []T, ds *dataset.DataSet[T]) T, partial_diff func(j int, theta []T, ds *dataset.DataSet[T]) (T, error), ds *dataset.DataSet[T], opts GradientCheckOptions) (GradientCheck, error) {
+	opts = check_options(opts, ds)
+	r := rand.New(new_rng(opts.Seed))
+	check := GradientCheck{MaxRelErr: make([]float64, opts.Params)}
+
+	for range opts.Points {
+		theta := random_params[T](r, opts)
+
+		for j := range theta {
+			analytic, err := partial_diff(j, theta, ds)
+			if err != nil {
+				return check, err
+			}
+
+			step := T(opts.Step * max(1, math.Abs(float64(theta[j]))))
+			orig := theta[j]
+
+			theta[j] = orig + step
+			up := cost(theta, ds)
+			theta[j] = orig - step
+			down := cost(theta, ds)
+			theta[j] = orig
+
+			check.record(j, float64(analytic), float64(up-down)/(2*float64(step)))
+		}
+	}
+
+	return check, nil
+}
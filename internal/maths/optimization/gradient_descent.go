@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"math/rand/v2"
 	"runtime"
 	"slices"
 	"sync"
@@ -13,14 +14,32 @@ import (
 	"golang.org/x/exp/constraints"
 )
 
+// One convergence check of an optimizer run
+type HistoryEntry[T constraints.Float] struct {
+	Epoch    int
+	Cost     T
+	GradNorm T // left to 0 by derivative free optimizers
+}
+
 // Stochastic Gradient Descent
 type GradientDescent[T constraints.Float] struct {
 	theta           []T
+	epoch           int // next epoch to run
+	history         []HistoryEntry[T]
+	rng             *rand.PCG
+	restored        bool
 	BatchSize       int
+	Sampler         Sampler // mini-batch sampling. The whole dataset is a single batch when it is not larger than BatchSize, unless rows are drawn with replacement or weights
 	Alpha           float32 // learning rate
+	Seed            uint64  // seed of the batch shuffling. 0 picks a random one
+	WarmStart       bool    // start from the parameters given to SetParams instead of resetting them
+	CheckpointPath  string  // file written every CheckpointEvery epochs when set
+	CheckpointEvery int
 	Threshold       maths.Threshold
+	Projection      Projection[T] // constraint set theta is projected onto after each update, unconstrained when nil
 	Cost            func(theta []T, ds *dataset.DataSet[T]) T
 	CostPartialDiff func(j int, theta []T, ds *dataset.DataSet[T]) (T, error)
+	CostGradient    func(theta []T, ds *dataset.DataSet[T]) (map[int]T, error) // non zero partial derivatives by parameter index, used instead of CostPartialDiff when set. See LinearGradient
 }
 
 // Stochastic Gradient Descent
@@ -42,34 +61,158 @@ func (g *GradientDescent[T]) GetParams() []T {
 	return g.theta
 }
 
+// Parameters used as starting point by Fit when WarmStart is set
+func (g *GradientDescent[T]) SetParams(theta []T) {
+	g.theta = slices.Clone(theta)
+}
+
+func (g *GradientDescent[T]) GetHistory() []HistoryEntry[T] {
+	return g.history
+}
+
+// Number of epochs run so far
+func (g *GradientDescent[T]) Epoch() int {
+	return g.epoch
+}
+
+// Starting point shared by optimizers : zero weights and the target mean as bias
+func initial_params[T constraints.Float](ds *dataset.DataSet[T]) []T {
+	theta := make([]T, ds.FeatCount()+1)
+	theta[0] = ds.TargetMean()
+	return theta
+}
+
 func (g *GradientDescent[T]) initialize_parameters(ds *dataset.DataSet[T]) {
-	g.theta = make([]T, ds.FeatCount()+1)
-	g.theta[0] = ds.TargetMean()
+	g.theta = initial_params(ds)
 }
 
-func (g *GradientDescent[T]) gradient_norm(ds *dataset.DataSet[T]) T {
-	grad := make([]T, len(g.theta))
-	num_workers := min(runtime.NumCPU(), len(g.theta))
+// Random source of a seed. 0 picks a random one
+func new_rng(seed uint64) *rand.PCG {
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return rand.NewPCG(seed, seed^0x9e3779b97f4a7c15)
+}
+
+func (g *GradientDescent[T]) initialize_rng() {
+	g.rng = new_rng(g.Seed)
+}
+
+// Picks the starting point of Fit : a restored checkpoint, the warm start parameters or fresh ones.
+// Resuming from parameters that do not match the features of ds is an error
+func (g *GradientDescent[T]) prepare(ds *dataset.DataSet[T]) error {
+	resumable := len(g.theta) == ds.FeatCount()+1
+
+	switch {
+	case g.restored && !resumable:
+		g.restored = false
+		return fmt.Errorf("GradientDescent.Fit : restored checkpoint has %d parameters, expected %d", len(g.theta), ds.FeatCount()+1)
+	case g.WarmStart && g.theta != nil && !resumable:
+		return fmt.Errorf("GradientDescent.Fit : warm start parameters have length %d, expected %d", len(g.theta), ds.FeatCount()+1)
+	case g.restored:
+	case g.WarmStart && g.theta != nil:
+		g.epoch = 0
+		g.history = nil
+	default:
+		g.initialize_parameters(ds)
+		g.epoch = 0
+		g.history = nil
+		g.rng = nil
+	}
+
+	if g.rng == nil {
+		g.initialize_rng()
+	}
+	g.restored = false
+
+	if p, ok := g.Projection.(sized_projection); ok {
+		if err := p.validate(len(g.theta)); err != nil {
+			return fmt.Errorf("GradientDescent.Fit : %v", err)
+		}
+	}
+
+	if g.Projection != nil {
+		g.Projection.Project(g.theta)
+	}
+
+	return nil
+}
+
+// Evaluate every partial derivative of the cost in parallel
+func gradient[T constraints.Float](partial_diff func(j int, theta []T, ds *dataset.DataSet[T]) (T, error), theta []T, ds *dataset.DataSet[T]) ([]T, error) {
+	grad := make([]T, len(theta))
+	num_workers := min(runtime.NumCPU(), len(theta))
 
 	var wg sync.WaitGroup
-	jobs := make(chan int, len(g.theta))
+	jobs := make(chan int, len(theta))
+	err_ch := make(chan error, len(theta))
 
 	for range num_workers {
 		wg.Go(func() {
 			for j := range jobs {
-				g_j, _ := g.CostPartialDiff(j, g.theta, ds)
+				g_j, err := partial_diff(j, theta, ds)
+				if err != nil {
+					err_ch <- err
+					continue
+				}
 				grad[j] = g_j
 			}
 		})
 	}
 
-	for j := range g.theta {
+	for j := range theta {
 		jobs <- j
 	}
 	close(jobs)
 
 	wg.Wait()
-	return maths.L2Norm(slices.Values(grad))
+	close(err_ch)
+
+	for err := range err_ch {
+		return grad, err
+	}
+
+	return grad, nil
+}
+
+func (g *GradientDescent[T]) eval_gradient(theta []T, ds *dataset.DataSet[T]) ([]T, error) {
+	if g.CostGradient == nil {
+		return gradient(g.CostPartialDiff, theta, ds)
+	}
+
+	sparse_grad, err := g.CostGradient(theta, ds)
+	grad := make([]T, len(theta))
+	for j, d := range sparse_grad {
+		grad[j] = d
+	}
+	return grad, err
+}
+
+func not_finite[T constraints.Float](v T) bool {
+	return math.IsNaN(float64(v)) || math.IsInf(float64(v), 0)
+}
+
+func diverged(j, epoch int) error {
+	return fmt.Errorf("GradientDescent.Fit : parameter %d diverged at epoch %d", j, epoch+1)
+}
+
+// With a projection, this is the norm of the projected gradient, which vanishes at a constrained minimum
+func (g *GradientDescent[T]) gradient_norm(ds *dataset.DataSet[T]) T {
+	grad, _ := g.eval_gradient(g.theta, ds)
+	if g.Projection == nil {
+		return maths.L2Norm(slices.Values(grad))
+	}
+
+	step := make([]T, len(g.theta))
+	for j := range step {
+		step[j] = g.theta[j] - T(g.Alpha)*grad[j]
+	}
+	g.Projection.Project(step)
+
+	for j := range step {
+		step[j] = (g.theta[j] - step[j]) / T(g.Alpha)
+	}
+	return maths.L2Norm(slices.Values(step))
 }
 
 func (g *GradientDescent[T]) process(ds *dataset.DataSet[T]) error {
@@ -77,21 +220,27 @@ func (g *GradientDescent[T]) process(ds *dataset.DataSet[T]) error {
 		return errors.New("No cost function supplied")
 	}
 
-	if g.CostPartialDiff == nil {
+	if g.CostPartialDiff == nil && g.CostGradient == nil {
 		return errors.New("No partial derivative function supplied")
 	}
 
 	sample_size := int(ds.Size())
 	prev_cost := g.Cost(g.theta, ds)
 	n_theta := make([]T, len(g.theta))
+	rng := rand.New(g.rng)
 
-	for epoch := 0; epoch < int(g.Threshold.MaxEpochs); epoch++ {
+	for g.epoch < int(g.Threshold.MaxEpochs) {
+		epoch := g.epoch
 		var batches []*dataset.DataSet[T]
 
-		if sample_size > g.BatchSize {
-			ds.Shuffle()
-			for batch_i := 0; batch_i < sample_size; batch_i += g.BatchSize {
-				batch, err := ds.Extract(float32(batch_i)/float32(sample_size), float32(batch_i+g.BatchSize)/float32(sample_size))
+		if sample_size > g.BatchSize || g.Sampler.Replacement || g.Sampler.Weights != nil {
+			rows, err := g.Sampler.Batches(sample_size, min(g.BatchSize, sample_size), rng)
+			if err != nil {
+				return err
+			}
+
+			for _, idx := range rows {
+				batch, err := ds.Select(idx)
 				if err != nil {
 					return err
 				}
@@ -102,6 +251,28 @@ func (g *GradientDescent[T]) process(ds *dataset.DataSet[T]) error {
 		}
 
 		for _, batch := range batches {
+			if g.CostGradient != nil {
+				grad, err := g.CostGradient(g.theta, batch)
+				if err != nil {
+					return err
+				}
+
+				// only the touched coordinates move, so a batch costs its non zero features rather than the whole theta
+				for j, d := range grad {
+					if not_finite(g.theta[j] - T(g.Alpha)*d) {
+						return diverged(j, epoch)
+					}
+				}
+				for j, d := range grad {
+					g.theta[j] -= T(g.Alpha) * d
+				}
+
+				if g.Projection != nil {
+					g.Projection.Project(g.theta)
+				}
+				continue
+			}
+
 			var wg sync.WaitGroup
 			num_workers := min(runtime.NumCPU(), len(g.theta))
 
@@ -118,8 +289,9 @@ func (g *GradientDescent[T]) process(ds *dataset.DataSet[T]) error {
 						}
 
 						n_theta[j] = g.theta[j] - T(g.Alpha)*c
-						if math.IsNaN(float64(n_theta[j])) || math.IsInf(float64(n_theta[j]), 0) {
-							panic("WTF ?")
+						if not_finite(n_theta[j]) {
+							err_ch <- diverged(j, epoch)
+							return
 						}
 					}
 				})
@@ -138,31 +310,51 @@ func (g *GradientDescent[T]) process(ds *dataset.DataSet[T]) error {
 			}
 
 			copy(g.theta, n_theta)
+			if g.Projection != nil {
+				g.Projection.Project(g.theta)
+			}
 		}
+		g.epoch++
+		converged := false
 
 		if epoch >= g.Threshold.MinEphocs {
 			grad_norm := g.gradient_norm(ds)
-			if grad_norm <= T(g.Threshold.GradEps) {
-				fmt.Printf("Hitting gradient breakpoint. Total epochs : %d\n", epoch+1)
-				break
-			}
-
 			cost := g.Cost(g.theta, ds)
+			g.history = append(g.history, HistoryEntry[T]{epoch + 1, cost, grad_norm})
+
 			rel_cost := math.Abs(float64(cost-prev_cost)) / max(1, math.Abs(float64(prev_cost)))
 			prev_cost = cost
 
-			if rel_cost <= float64(g.Threshold.CostEps) {
+			if grad_norm <= T(g.Threshold.GradEps) {
+				fmt.Printf("Hitting gradient breakpoint. Total epochs : %d\n", epoch+1)
+				converged = true
+			} else if rel_cost <= float64(g.Threshold.CostEps) {
 				fmt.Printf("Hitting cost breakpoint. Total epochs : %d\n", epoch+1)
-				break
+				converged = true
+			}
+		}
+
+		// the last epoch is always written, so that resuming does not redo any of them
+		last := converged || g.epoch >= int(g.Threshold.MaxEpochs)
+		if g.CheckpointPath != "" && g.CheckpointEvery > 0 && (last || g.epoch%g.CheckpointEvery == 0) {
+			if err := g.Checkpoint(g.CheckpointPath); err != nil {
+				return err
 			}
 		}
+
+		if converged {
+			break
+		}
 	}
 
 	return nil
 }
 
+// Fit continues from the last restored checkpoint if any, see Restore
 func (g *GradientDescent[T]) Fit(ds *dataset.DataSet[T]) error {
-	g.initialize_parameters(ds)
+	if err := g.prepare(ds); err != nil {
+		return err
+	}
 	if err := g.process(ds); err != nil {
 		return err
 	}
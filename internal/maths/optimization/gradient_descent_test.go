@@ -1,10 +1,14 @@
 package optimization
 
 import (
+	"bytes"
 	"fmt"
 	"math"
+	"math/rand/v2"
+	"path/filepath"
 	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/bleak-and-bare/machine_learning/internal/dataset"
@@ -46,12 +50,13 @@ func linear_reg_cost_partial_diff[T constraints.Float](j int, theta []T, ds *dat
 }
 
 func linear_reg_cost[T constraints.Float](theta []T, ds *dataset.DataSet[T]) T {
-	return MSE(theta, ds, func(theta []T, x []T) T {
+	cost, _ := MSE(theta, ds, func(theta []T, x []T) T {
 		return theta[0] + vector.DotProduct(
 			iterable.Skip(slices.Values(theta), 1),
 			slices.Values(x),
 		)
 	}, 0.0)
+	return cost
 }
 
 func TestGradientDescent(t *testing.T) {
@@ -80,3 +85,316 @@ func TestGradientDescent(t *testing.T) {
 		t.Errorf("Wrong parameter values : [%.3f, %.3f] != [1, 0]", theta[1], theta[0])
 	}
 }
+
+func TestGradientDescent_Checkpoint(t *testing.T) {
+	ds := dataset.NewDataSet[float32](1)
+	str := strings.NewReader(`x,y
+0,0
+1,1
+2,2`)
+
+	ds.LoadCsvReader(str, ',')
+	th := maths.DefThreshold()
+	th.MaxEpochs = 50
+
+	sgd := NewSGD[float32](th)
+	sgd.Seed = 42
+	sgd.Cost = linear_reg_cost
+	sgd.CostPartialDiff = linear_reg_cost_partial_diff
+
+	if err := sgd.Fit(&ds); err != nil {
+		t.Errorf("SGD.Fit should not error : %v", err)
+		t.FailNow()
+	}
+
+	var buf bytes.Buffer
+	if err := sgd.CheckpointWriter(&buf); err != nil {
+		t.Errorf("SGD.Checkpoint should not error : %v", err)
+		t.FailNow()
+	}
+
+	resumed := NewSGD[float32](th)
+	resumed.Cost = linear_reg_cost
+	resumed.CostPartialDiff = linear_reg_cost_partial_diff
+	if err := resumed.RestoreReader(&buf); err != nil {
+		t.Errorf("SGD.Restore should not error : %v", err)
+		t.FailNow()
+	}
+
+	if !slices.Equal(resumed.GetParams(), sgd.GetParams()) || resumed.Epoch() != sgd.Epoch() {
+		t.Error("Restored state differs from checkpoint")
+	}
+
+	if len(resumed.GetHistory()) != len(sgd.GetHistory()) {
+		t.Error("History was not restored")
+	}
+
+	resumed.Threshold.MaxEpochs = 60
+	if err := resumed.Fit(&ds); err != nil {
+		t.Errorf("SGD.Fit should not error : %v", err)
+		t.FailNow()
+	}
+
+	if resumed.Epoch() != 60 {
+		t.Errorf("Training should resume at epoch 50 and stop at 60, stopped at %d", resumed.Epoch())
+	}
+
+	wide := dataset.NewDataSet[float32](2)
+	wide.LoadCsvReader(strings.NewReader("a,b,y\n0,1,1\n1,0,1"), ',')
+
+	mismatched := NewSGD[float32](th)
+	mismatched.Cost = linear_reg_cost
+	mismatched.CostPartialDiff = linear_reg_cost_partial_diff
+	mismatched.WarmStart = true
+	mismatched.SetParams(sgd.GetParams())
+	if err := mismatched.Fit(&wide); err == nil {
+		t.Error("Warm starting from parameters of another feature count should error")
+	}
+
+	// the last epoch is written even when it is not a multiple of CheckpointEvery
+	path := filepath.Join(t.TempDir(), "sgd.ckpt")
+	periodic := NewSGD[float32](th)
+	periodic.Cost = linear_reg_cost
+	periodic.CostPartialDiff = linear_reg_cost_partial_diff
+	periodic.CheckpointPath = path
+	periodic.CheckpointEvery = 1000
+	if err := periodic.Fit(&ds); err != nil {
+		t.Errorf("SGD.Fit should not error : %v", err)
+		t.FailNow()
+	}
+
+	last := NewSGD[float32](th)
+	if err := last.Restore(path); err != nil || last.Epoch() != periodic.Epoch() {
+		t.Errorf("Final state should be checkpointed : epoch %d instead of %d, %v", last.Epoch(), periodic.Epoch(), err)
+	}
+}
+
+func TestGradientDescent_KeepsRowOrder(t *testing.T) {
+	ds := dataset.NewDataSet[float32](1)
+	str := strings.NewReader(`x,y
+0,0
+1,1
+2,2
+3,3
+4,4`)
+
+	ds.LoadCsvReader(str, ',')
+	th := maths.DefThreshold()
+	th.MaxEpochs = 20
+
+	sgd := NewSGD[float32](th)
+	sgd.BatchSize = 2
+	sgd.Cost = linear_reg_cost
+	sgd.CostPartialDiff = linear_reg_cost_partial_diff
+
+	if err := sgd.Fit(&ds); err != nil {
+		t.Errorf("SGD.Fit should not error : %v", err)
+		t.FailNow()
+	}
+
+	for s := range ds.Samples() {
+		if *s.GetFeat(0) != float32(s.GetRow()) {
+			t.Errorf("Row %d has been moved by training", s.GetRow())
+		}
+	}
+}
+
+func TestGradientDescent_SmallDataSetSampler(t *testing.T) {
+	ds := dataset.NewDataSet[float32](1)
+	ds.LoadCsvReader(strings.NewReader("x,y\n0,0\n1,1\n2,100"), ',')
+
+	th := maths.DefThreshold()
+	th.MaxEpochs = 5
+
+	sgd := NewSGD[float32](th)
+	sgd.Sampler = Sampler{Replacement: true, Weights: []float64{1, 1, 0}}
+	sgd.Cost = linear_reg_cost
+	var sampled atomic.Bool
+	sgd.CostPartialDiff = func(j int, theta []float32, batch *dataset.DataSet[float32]) (float32, error) {
+		// the full dataset is still used for the convergence checks
+		if batch != &ds {
+			sampled.Store(true)
+			for s := range batch.Samples() {
+				if *s.GetFeat(0) == 2 {
+					t.Error("Row with a zero weight was drawn")
+				}
+			}
+		}
+		return linear_reg_cost_partial_diff(j, theta, batch)
+	}
+
+	if err := sgd.Fit(&ds); err != nil {
+		t.Errorf("SGD.Fit should not error : %v", err)
+	}
+
+	if !sampled.Load() {
+		t.Error("Sampler was bypassed although it draws with replacement")
+	}
+}
+
+func TestSampler_Batches(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	tests := []struct {
+		name    string
+		sampler Sampler
+		batches int
+		rows    int
+	}{
+		{"Without replacement", Sampler{}, 4, 10},
+		{"Drop last", Sampler{DropLast: true}, 3, 9},
+		{"With replacement", Sampler{Replacement: true}, 4, 10},
+		{"Zero weights are skipped", Sampler{Weights: []float64{1, 0, 1, 0, 1, 0, 1, 0, 1, 0}}, 2, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batches, err := tt.sampler.Batches(10, 3, r)
+			if err != nil {
+				t.Errorf("Sampler.Batches should not error : %v", err)
+				t.FailNow()
+			}
+
+			if len(batches) != tt.batches {
+				t.Errorf("Wrong number of batches : %d. Expected : %d", len(batches), tt.batches)
+			}
+
+			seen := make(map[int]bool)
+			rows := 0
+			for _, b := range batches {
+				for _, i := range b {
+					if tt.sampler.Weights != nil && tt.sampler.Weights[i] == 0 {
+						t.Errorf("Row %d has a zero weight but was drawn", i)
+					}
+					if !tt.sampler.Replacement && seen[i] {
+						t.Errorf("Row %d drawn twice without replacement", i)
+					}
+					seen[i] = true
+					rows++
+				}
+			}
+
+			if rows != tt.rows {
+				t.Errorf("Wrong number of rows drawn : %d. Expected : %d", rows, tt.rows)
+			}
+		})
+	}
+}
+
+func TestProjection(t *testing.T) {
+	theta := []float64{-3, 0.5, 2, -1}
+
+	Simplex(1.0).Project(theta)
+	if theta[0] != -3 {
+		t.Error("Simplex projection should leave the bias free")
+	}
+
+	var sum float64
+	for _, w := range theta[1:] {
+		if w < 0 {
+			t.Errorf("Negative coefficient after simplex projection : %v", theta)
+		}
+		sum += w
+	}
+
+	if math.Abs(sum-1) > 1e-12 {
+		t.Errorf("Coefficients sum to %.3f instead of 1", sum)
+	}
+
+	box := Box(Bounds[float64]{Lower: []float64{-1, -1, -1, -1}, Upper: []float64{1, 1, 1, 1}})
+	theta = []float64{-3, 0.5, 2, -1}
+	box.Project(theta)
+	if !slices.Equal(theta, []float64{-1, 0.5, 1, -1}) {
+		t.Errorf("Wrong box projection : %v", theta)
+	}
+
+	if active := box.Active(theta); len(active) != 3 {
+		t.Errorf("Wrong active constraints : %v", active)
+	}
+
+	ds := dataset.NewDataSet[float64](1)
+	ds.LoadCsvReader(strings.NewReader("x,y\n0,0\n1,1"), ',')
+
+	sgd := NewSGD[float64](maths.DefThreshold())
+	sgd.Cost = linear_reg_cost
+	sgd.CostPartialDiff = linear_reg_cost_partial_diff
+	sgd.Projection = box
+	if err := sgd.Fit(&ds); err == nil {
+		t.Error("Fitting 2 parameters within 4 bounds should error")
+	}
+}
+
+func TestCheckGradient(t *testing.T) {
+	ds := dataset.NewDataSet[float64](2)
+	str := strings.NewReader(`a,b,y
+0.5,1,1
+1,-2,3
+2,0.3,0`)
+
+	ds.LoadCsvReader(str, ',')
+
+	check, err := CheckGradient(&linear_reg_hypo[float64]{}, &ds, GradientCheckOptions{Seed: 1})
+	if err != nil || check.Worst > 1e-6 {
+		t.Errorf("Linear hypothesis gradient check failed : %v, %v", check.MaxRelErr, err)
+	}
+
+	check, err = CheckCostGradient(linear_reg_cost, linear_reg_cost_partial_diff, &ds, GradientCheckOptions{Seed: 1})
+	if err != nil || check.Worst > 1e-6 {
+		t.Errorf("MSE gradient check failed : %v, %v", check.MaxRelErr, err)
+	}
+
+	// a wrong derivative regarding the bias must be caught
+	wrong := func(j int, theta []float64, ds *dataset.DataSet[float64]) (float64, error) {
+		d, err := linear_reg_cost_partial_diff(j, theta, ds)
+		if j == 0 {
+			d *= 2
+		}
+		return d, err
+	}
+
+	check, _ = CheckCostGradient(linear_reg_cost, wrong, &ds, GradientCheckOptions{Seed: 1})
+	if check.MaxRelErr[0] < 1e-2 || check.MaxRelErr[1] > 1e-6 {
+		t.Errorf("Gradient check should only flag theta[0] : %v", check.MaxRelErr)
+	}
+}
+
+// A whole gradient only moves the coordinates it holds, and a diverging step is an error
+func TestGradientDescent_CostGradient(t *testing.T) {
+	ds := dataset.NewDataSet[float64](2)
+	ds.LoadCsvReader(strings.NewReader("a,b,y\n1,0,3\n2,5,1\n0,1,-2"), ',')
+
+	th := maths.DefThreshold()
+	th.MaxEpochs = 1
+
+	sgd := NewSGD[float64](th)
+	sgd.Alpha = 1
+	sgd.Cost = linear_reg_cost
+	sgd.CostGradient = func(theta []float64, ds *dataset.DataSet[float64]) (map[int]float64, error) {
+		return map[int]float64{0: 1, 2: -1}, nil
+	}
+
+	if err := sgd.Fit(&ds); err != nil {
+		t.Errorf("SGD.Fit should not error : %v", err)
+		t.FailNow()
+	}
+
+	if theta := sgd.GetParams(); theta[1] != 0 || theta[2] != 1 {
+		t.Errorf("Wrong parameters after one step : %v", theta)
+	}
+
+	sgd.CostGradient = func(theta []float64, ds *dataset.DataSet[float64]) (map[int]float64, error) {
+		return map[int]float64{1: math.Inf(1)}, nil
+	}
+
+	if err := sgd.Fit(&ds); err == nil {
+		t.Error("SGD.Fit should error when a parameter diverges")
+	}
+
+	sgd.CostGradient = nil
+	sgd.CostPartialDiff = func(j int, theta []float64, ds *dataset.DataSet[float64]) (float64, error) {
+		return math.NaN(), nil
+	}
+
+	if err := sgd.Fit(&ds); err == nil {
+		t.Error("SGD.Fit should error when a parameter diverges through CostPartialDiff")
+	}
+}
@@ -0,0 +1,310 @@
+package optimization
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"github.com/bleak-and-bare/machine_learning/internal/maths"
+	"golang.org/x/exp/constraints"
+)
+
+// Limited memory BFGS. Full batch quasi-Newton method with a strong Wolfe line search
+type LBFGS[T constraints.Float] struct {
+	theta           []T
+	history         []HistoryEntry[T]
+	Memory          int // number of correction pairs kept to approximate the inverse Hessian
+	Threshold       maths.Threshold
+	Cost            func(theta []T, ds *dataset.DataSet[T]) T
+	CostPartialDiff func(j int, theta []T, ds *dataset.DataSet[T]) (T, error)
+}
+
+func NewLBFGS[T constraints.Float](t maths.Threshold) LBFGS[T] {
+	return LBFGS[T]{
+		Memory:    10,
+		Threshold: t,
+	}
+}
+
+func (o *LBFGS[T]) GetParams() []T {
+	return o.theta
+}
+
+func (o *LBFGS[T]) GetHistory() []HistoryEntry[T] {
+	return o.history
+}
+
+// Set Cost and CostPartialDiff to minimize the mean loss l of the hypothesis h
+func (o *LBFGS[T]) UseLoss(h Hypothesis[T], l Loss[T]) {
+	o.Cost, o.CostPartialDiff = loss_callbacks(h, l)
+}
+
+func (o *LBFGS[T]) Fit(ds *dataset.DataSet[T]) error {
+	if o.Cost == nil {
+		return errors.New("No cost function supplied")
+	}
+
+	if o.CostPartialDiff == nil {
+		return errors.New("No partial derivative function supplied")
+	}
+
+	o.theta = initial_params(ds)
+	o.history = nil
+	f := objective[T]{o.Cost, o.CostPartialDiff, ds}
+
+	x := to_f64(o.theta)
+	fx, gx, err := f.eval(x)
+	if err != nil {
+		return err
+	}
+
+	memory := max(1, o.Memory)
+	var s_list, y_list [][]float64
+
+	for iter := 0; iter < o.Threshold.MaxEpochs; iter++ {
+		d := two_loop(gx, s_list, y_list)
+		if dot(d, gx) >= 0 {
+			// lost descent direction, restart from steepest descent
+			s_list, y_list = nil, nil
+			d = scale(gx, -1)
+		}
+
+		step := 1.0
+		if len(s_list) == 0 {
+			step = 1 / max(1, norm(gx))
+		}
+
+		x_new, f_new, g_new, err := wolfe_search(&f, x, fx, gx, d, step)
+		if err != nil {
+			if len(s_list) == 0 {
+				return err
+			}
+			s_list, y_list = nil, nil
+			continue
+		}
+
+		s, y := sub(x_new, x), sub(g_new, gx)
+		if dot(s, y) > 1e-10*norm(s)*norm(y) {
+			s_list, y_list = append(s_list, s), append(y_list, y)
+			if len(s_list) > memory {
+				s_list, y_list = s_list[1:], y_list[1:]
+			}
+		}
+
+		prev := fx
+		x, fx, gx = x_new, f_new, g_new
+		grad_norm := norm(gx)
+		o.history = append(o.history, HistoryEntry[T]{iter + 1, T(fx), T(grad_norm)})
+
+		if iter+1 >= o.Threshold.MinEphocs {
+			if grad_norm <= float64(o.Threshold.GradEps) {
+				fmt.Printf("Hitting gradient breakpoint. Total iterations : %d\n", iter+1)
+				break
+			}
+
+			if math.Abs(fx-prev)/max(1, math.Abs(prev)) <= float64(o.Threshold.CostEps) {
+				fmt.Printf("Hitting cost breakpoint. Total iterations : %d\n", iter+1)
+				break
+			}
+		}
+	}
+
+	o.theta = from_f64[T](x)
+	return nil
+}
+
+// Approximate the Newton direction -H^-1 * g from the correction pairs
+func two_loop(g []float64, s_list, y_list [][]float64) []float64 {
+	q := scale(g, -1)
+	k := len(s_list)
+	alpha := make([]float64, k)
+
+	for i := k - 1; i >= 0; i-- {
+		rho := 1 / dot(y_list[i], s_list[i])
+		alpha[i] = rho * dot(s_list[i], q)
+		axpy(-alpha[i], y_list[i], q)
+	}
+
+	if k > 0 {
+		gamma := dot(s_list[k-1], y_list[k-1]) / dot(y_list[k-1], y_list[k-1])
+		q = scale(q, gamma)
+	}
+
+	for i := range k {
+		rho := 1 / dot(y_list[i], s_list[i])
+		beta := rho * dot(y_list[i], q)
+		axpy(alpha[i]-beta, s_list[i], q)
+	}
+
+	return q
+}
+
+// Line search along d satisfying the strong Wolfe conditions (Nocedal & Wright, algorithm 3.5)
+func wolfe_search[T constraints.Float](f *objective[T], x []float64, fx float64, gx, d []float64, step float64) ([]float64, float64, []float64, error) {
+	const c1, c2 = 1e-4, 0.9
+	const max_evals = 30
+
+	dg0 := dot(gx, d)
+	prev_step, prev_f := 0.0, fx
+
+	type point struct {
+		step float64
+		f    float64
+	}
+
+	try := func(a float64) ([]float64, float64, []float64, error) {
+		x_a := add_scaled(x, a, d)
+		f_a, g_a, err := f.eval(x_a)
+		return x_a, f_a, g_a, err
+	}
+
+	zoom := func(lo, hi point) ([]float64, float64, []float64, error) {
+		for range max_evals {
+			a := (lo.step + hi.step) / 2
+			x_a, f_a, g_a, err := try(a)
+			if err != nil {
+				return nil, 0, nil, err
+			}
+
+			if f_a > fx+c1*a*dg0 || f_a >= lo.f {
+				hi = point{a, f_a}
+				continue
+			}
+
+			dg := dot(g_a, d)
+			if math.Abs(dg) <= -c2*dg0 {
+				return x_a, f_a, g_a, nil
+			}
+
+			if dg*(hi.step-lo.step) >= 0 {
+				hi = lo
+			}
+			lo = point{a, f_a}
+		}
+
+		if lo.step > 0 {
+			x_a, f_a, g_a, err := try(lo.step)
+			return x_a, f_a, g_a, err
+		}
+
+		return nil, 0, nil, errors.New("LBFGS : line search failed")
+	}
+
+	for i := range max_evals {
+		x_a, f_a, g_a, err := try(step)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+
+		if math.IsNaN(f_a) || math.IsInf(f_a, 0) {
+			step = (prev_step + step) / 2
+			continue
+		}
+
+		if f_a > fx+c1*step*dg0 || (i > 0 && f_a >= prev_f) {
+			return zoom(point{prev_step, prev_f}, point{step, f_a})
+		}
+
+		dg := dot(g_a, d)
+		if math.Abs(dg) <= -c2*dg0 {
+			return x_a, f_a, g_a, nil
+		}
+
+		if dg >= 0 {
+			return zoom(point{step, f_a}, point{prev_step, prev_f})
+		}
+
+		prev_step, prev_f = step, f_a
+		step *= 2
+	}
+
+	return nil, 0, nil, errors.New("LBFGS : line search failed")
+}
+
+// Cost and gradient callbacks evaluated in float64
+type objective[T constraints.Float] struct {
+	cost         func(theta []T, ds *dataset.DataSet[T]) T
+	partial_diff func(j int, theta []T, ds *dataset.DataSet[T]) (T, error)
+	ds           *dataset.DataSet[T]
+}
+
+func (o *objective[T]) value(x []float64) float64 {
+	return float64(o.cost(from_f64[T](x), o.ds))
+}
+
+func (o *objective[T]) grad(x []float64) ([]float64, error) {
+	g, err := gradient(o.partial_diff, from_f64[T](x), o.ds)
+	if err != nil {
+		return nil, err
+	}
+	return to_f64(g), nil
+}
+
+func (o *objective[T]) eval(x []float64) (float64, []float64, error) {
+	g, err := o.grad(x)
+	if err != nil {
+		return 0, nil, err
+	}
+	return o.value(x), g, nil
+}
+
+func to_f64[T constraints.Float](v []T) []float64 {
+	r := make([]float64, len(v))
+	for i := range v {
+		r[i] = float64(v[i])
+	}
+	return r
+}
+
+func from_f64[T constraints.Float](v []float64) []T {
+	r := make([]T, len(v))
+	for i := range v {
+		r[i] = T(v[i])
+	}
+	return r
+}
+
+func dot(v, w []float64) float64 {
+	var sum float64
+	for i := range v {
+		sum += v[i] * w[i]
+	}
+	return sum
+}
+
+func norm(v []float64) float64 {
+	return math.Sqrt(dot(v, v))
+}
+
+func scale(v []float64, a float64) []float64 {
+	r := make([]float64, len(v))
+	for i := range v {
+		r[i] = a * v[i]
+	}
+	return r
+}
+
+func sub(v, w []float64) []float64 {
+	r := make([]float64, len(v))
+	for i := range v {
+		r[i] = v[i] - w[i]
+	}
+	return r
+}
+
+// v + a*w
+func add_scaled(v []float64, a float64, w []float64) []float64 {
+	r := make([]float64, len(v))
+	for i := range v {
+		r[i] = v[i] + a*w[i]
+	}
+	return r
+}
+
+// w += a*v
+func axpy(a float64, v, w []float64) {
+	for i := range v {
+		w[i] += a * v[i]
+	}
+}
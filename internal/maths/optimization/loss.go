@@ -0,0 +1,393 @@
+package optimization
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"golang.org/x/exp/constraints"
+)
+
+// Loss of a single prediction
+type Loss[T constraints.Float] interface {
+	// Loss value of the prediction pred regarding the target y
+	Value(y, pred T) T
+
+	// Derivative of the loss regarding the prediction
+	Diff(y, pred T) T
+}
+
+// Loss with a second derivative regarding the prediction, which lets Newton build its Hessian
+type TwiceDiffLoss[T constraints.Float] interface {
+	Loss[T]
+	SecondDiff(y, pred T) T
+}
+
+type squared_err[T constraints.Float] struct{}
+
+// (pred - y)^2. Same as MSE once averaged
+func SquaredErr[T constraints.Float]() Loss[T] {
+	return squared_err[T]{}
+}
+
+func (squared_err[T]) Value(y, pred T) T {
+	return (pred - y) * (pred - y)
+}
+
+func (squared_err[T]) Diff(y, pred T) T {
+	return 2 * (pred - y)
+}
+
+func (squared_err[T]) SecondDiff(y, pred T) T {
+	return 2
+}
+
+type absolute_err[T constraints.Float] struct{}
+
+// |pred - y|. Mean absolute error once averaged
+func MAE[T constraints.Float]() Loss[T] {
+	return absolute_err[T]{}
+}
+
+func (absolute_err[T]) Value(y, pred T) T {
+	return T(math.Abs(float64(pred - y)))
+}
+
+func (absolute_err[T]) Diff(y, pred T) T {
+	return sign(pred - y)
+}
+
+type huber[T constraints.Float] struct {
+	delta T
+}
+
+// Quadratic for residuals smaller than delta, linear beyond. delta must be positive
+func Huber[T constraints.Float](delta T) (Loss[T], error) {
+	if !(delta > 0) || math.IsInf(float64(delta), 0) {
+		return nil, fmt.Errorf("optimization.Huber : unsupported delta %v, expected a positive value", delta)
+	}
+	return huber[T]{delta}, nil
+}
+
+func (l huber[T]) Value(y, pred T) T {
+	r := pred - y
+	if a := T(math.Abs(float64(r))); a > l.delta {
+		return l.delta * (a - l.delta/2)
+	}
+	return r * r / 2
+}
+
+func (l huber[T]) Diff(y, pred T) T {
+	r := pred - y
+	if math.Abs(float64(r)) > float64(l.delta) {
+		return l.delta * sign(r)
+	}
+	return r
+}
+
+func (l huber[T]) SecondDiff(y, pred T) T {
+	if math.Abs(float64(pred-y)) > float64(l.delta) {
+		return 0
+	}
+	return 1
+}
+
+type log_cosh[T constraints.Float] struct{}
+
+// log(cosh(pred - y)). Behaves like the squared error near 0 and like the absolute error far from it
+func LogCosh[T constraints.Float]() Loss[T] {
+	return log_cosh[T]{}
+}
+
+func (log_cosh[T]) Value(y, pred T) T {
+	// log(cosh(r)) = |r| + log(1 + exp(-2|r|)) - log(2), without overflowing cosh
+	a := math.Abs(float64(pred - y))
+	return T(a + math.Log1p(math.Exp(-2*a)) - math.Ln2)
+}
+
+func (log_cosh[T]) Diff(y, pred T) T {
+	return T(math.Tanh(float64(pred - y)))
+}
+
+func (log_cosh[T]) SecondDiff(y, pred T) T {
+	th := math.Tanh(float64(pred - y))
+	return T(1 - th*th)
+}
+
+type quantile[T constraints.Float] struct {
+	q T
+}
+
+// Pinball loss. Fitting it estimates the q-th quantile of the target, q in ]0, 1[
+func Quantile[T constraints.Float](q T) (Loss[T], error) {
+	if !(q > 0 && q < 1) {
+		return nil, fmt.Errorf("optimization.Quantile : unsupported quantile %v, expected a value in ]0, 1[", q)
+	}
+	return quantile[T]{q}, nil
+}
+
+func (l quantile[T]) Value(y, pred T) T {
+	r := y - pred
+	if r >= 0 {
+		return l.q * r
+	}
+	return (l.q - 1) * r
+}
+
+func (l quantile[T]) Diff(y, pred T) T {
+	if y-pred >= 0 {
+		return -l.q
+	}
+	return 1 - l.q
+}
+
+type poisson[T constraints.Float] struct{}
+
+// Poisson deviance with a log link : the prediction is log(mu) where mu is the expected count
+func Poisson[T constraints.Float]() Loss[T] {
+	return poisson[T]{}
+}
+
+func (poisson[T]) Value(y, pred T) T {
+	mu := math.Exp(float64(pred))
+	return T(2 * (xlogy(float64(y), float64(y)) - float64(y*pred) - float64(y) + mu))
+}
+
+func (poisson[T]) Diff(y, pred T) T {
+	return T(2 * (math.Exp(float64(pred)) - float64(y)))
+}
+
+func (poisson[T]) SecondDiff(y, pred T) T {
+	return T(2 * math.Exp(float64(pred)))
+}
+
+type tweedie[T constraints.Float] struct {
+	power float64
+}
+
+// Tweedie deviance with a log link : the prediction is log(mu).
+// power 1 is the Poisson deviance, power 2 the Gamma one and 1 < power < 2 a compound Poisson-Gamma.
+// A power below 1 is an error : power 0 requires an identity link, use SquaredErr instead
+func Tweedie[T constraints.Float](power T) (Loss[T], error) {
+	if power < 1 || math.IsNaN(float64(power)) {
+		return nil, fmt.Errorf("optimization.Tweedie : unsupported power %v, expected at least 1", power)
+	}
+	return tweedie[T]{float64(power)}, nil
+}
+
+func (l tweedie[T]) Value(y, pred T) T {
+	p, yf := l.power, float64(y)
+	mu := math.Exp(float64(pred))
+
+	switch p {
+	case 1:
+		return poisson[T]{}.Value(y, pred)
+	case 2:
+		return T(2 * (float64(pred) - math.Log(yf) + yf/mu - 1))
+	}
+
+	return T(2 * (math.Pow(max(yf, 0), 2-p)/((1-p)*(2-p)) - yf*math.Pow(mu, 1-p)/(1-p) + math.Pow(mu, 2-p)/(2-p)))
+}
+
+func (l tweedie[T]) Diff(y, pred T) T {
+	p, yf := l.power, float64(y)
+	mu := math.Exp(float64(pred))
+	return T(2 * (math.Pow(mu, 2-p) - yf*math.Pow(mu, 1-p)))
+}
+
+func (l tweedie[T]) SecondDiff(y, pred T) T {
+	p, yf := l.power, float64(y)
+	mu := math.Exp(float64(pred))
+	return T(2 * ((2-p)*math.Pow(mu, 2-p) - (1-p)*yf*math.Pow(mu, 1-p)))
+}
+
+type hinge[T constraints.Float] struct{}
+
+// Hinge loss of a classifier score. Targets are either {0, 1} or {-1, 1}
+func Hinge[T constraints.Float]() Loss[T] {
+	return hinge[T]{}
+}
+
+func (hinge[T]) Value(y, pred T) T {
+	return max(0, 1-label_sign(y)*pred)
+}
+
+func (hinge[T]) Diff(y, pred T) T {
+	s := label_sign(y)
+	if s*pred < 1 {
+		return -s
+	}
+	return 0
+}
+
+type log_loss[T constraints.Float] struct{}
+
+// Binary cross entropy of a logit : the prediction is log(p / (1 - p)). Targets are {0, 1}
+func LogLoss[T constraints.Float]() Loss[T] {
+	return log_loss[T]{}
+}
+
+func (log_loss[T]) Value(y, pred T) T {
+	z := float64(pred)
+	// log(1 + exp(z)) - y*z, without overflowing exp
+	return T(max(z, 0) + math.Log1p(math.Exp(-math.Abs(z))) - float64(y)*z)
+}
+
+func (log_loss[T]) Diff(y, pred T) T {
+	return T(1/(1+math.Exp(-float64(pred)))) - y
+}
+
+func (log_loss[T]) SecondDiff(y, pred T) T {
+	p := 1 / (1 + math.Exp(-float64(pred)))
+	return T(p * (1 - p))
+}
+
+func sign[T constraints.Float](v T) T {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
+
+func label_sign[T constraints.Float](y T) T {
+	if y > 0 {
+		return 1
+	}
+	return -1
+}
+
+// x*log(y) with 0*log(0) = 0
+func xlogy(x, y float64) float64 {
+	if x == 0 {
+		return 0
+	}
+	return x * math.Log(y)
+}
+
+// Mean loss of the hypothesis over the dataset, weighted by the sample weights of ds if any.
+// Rows where the hypothesis can not be evaluated or without target are skipped
+func Cost[T constraints.Float](params []T, ds *dataset.DataSet[T], h Hypothesis[T], l Loss[T]) T {
+	var sum, total T
+
+	for s := range ds.Samples() {
+		y := s.GetTarget()
+		if y == nil {
+			continue
+		}
+
+		pred, err := h.On(params, &s)
+		if err != nil {
+			continue
+		}
+
+		w := s.GetWeight()
+		sum += w * l.Value(*y, pred)
+		total += w
+	}
+
+	if total == 0 {
+		return 0.0
+	}
+
+	return sum / total
+}
+
+// Partial derivative of Cost regarding params[j]. Rows skipped by Cost are skipped as well
+func PartialDiffCost[T constraints.Float](j int, params []T, ds *dataset.DataSet[T], h Hypothesis[T], l Loss[T]) (T, error) {
+	var sum, total T
+
+	for s := range ds.Samples() {
+		y := s.GetTarget()
+		if y == nil {
+			continue
+		}
+
+		pred, err := h.On(params, &s)
+		if err != nil {
+			continue
+		}
+
+		diff, err := h.Diff(j, params, &s)
+		if err != nil {
+			return 0.0, err
+		}
+
+		w := s.GetWeight()
+		sum += w * diff * l.Diff(*y, pred)
+		total += w
+	}
+
+	if total == 0 {
+		return 0.0, nil
+	}
+
+	return sum / total, nil
+}
+
+/*
+Gradient of Cost for the linear hypothesis params[0] + params[1:].x, in one pass over the dataset.
+Only the non zero partial derivatives are returned, by parameter index, the bias one always being there :
+each sample only costs its non zero features, while PartialDiffCost goes through every sample once per parameter.
+Rows skipped by Cost are skipped as well. l is the squared error when nil
+*/
+func LinearGradient[T constraints.Float](params []T, ds *dataset.DataSet[T], l Loss[T]) (map[int]T, error) {
+	if len(params) != ds.FeatCount()+1 {
+		return nil, fmt.Errorf("LinearGradient : %d parameters provided for %d features", len(params), ds.FeatCount())
+	}
+
+	if l == nil {
+		l = SquaredErr[T]()
+	}
+
+	grad := map[int]T{0: 0}
+	var total T
+
+	for s := range ds.Samples() {
+		y := s.GetTarget()
+		if y == nil {
+			continue
+		}
+
+		d, err := s.DotProduct(params[1:])
+		if err != nil {
+			continue
+		}
+
+		w := s.GetWeight()
+		r := w * l.Diff(*y, params[0]+d)
+		grad[0] += r
+		for i, x := range s.NonZeroFeats() {
+			grad[i+1] += r * x
+		}
+		total += w
+	}
+
+	if total == 0 {
+		return grad, nil
+	}
+
+	for j := range grad {
+		grad[j] /= total
+	}
+	return grad, nil
+}
+
+func loss_callbacks[T constraints.Float](h Hypothesis[T], l Loss[T]) (func(theta []T, ds *dataset.DataSet[T]) T, func(j int, theta []T, ds *dataset.DataSet[T]) (T, error)) {
+	cost := func(theta []T, ds *dataset.DataSet[T]) T {
+		return Cost(theta, ds, h, l)
+	}
+
+	partial_diff := func(j int, theta []T, ds *dataset.DataSet[T]) (T, error) {
+		return PartialDiffCost(j, theta, ds, h, l)
+	}
+
+	return cost, partial_diff
+}
+
+// Set Cost and CostPartialDiff to minimize the mean loss l of the hypothesis h
+func (g *GradientDescent[T]) UseLoss(h Hypothesis[T], l Loss[T]) {
+	g.Cost, g.CostPartialDiff = loss_callbacks(h, l)
+}
@@ -0,0 +1,278 @@
+package optimization
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+)
+
+func TestLoss_Diff(t *testing.T) {
+	tweedie, _ := Tweedie(1.5)
+	gamma, _ := Tweedie(2.0)
+	huber, _ := Huber(1.35)
+	quantile, _ := Quantile(0.9)
+	tests := []struct {
+		name string
+		loss Loss[float64]
+		y    float64
+	}{
+		{"Squared error", SquaredErr[float64](), 1.5},
+		{"Absolute error", MAE[float64](), 1.5},
+		{"Huber", huber, 1.5},
+		{"Log cosh", LogCosh[float64](), 1.5},
+		{"Quantile", quantile, 1.5},
+		{"Poisson", Poisson[float64](), 3},
+		{"Poisson zero count", Poisson[float64](), 0},
+		{"Tweedie", tweedie, 3},
+		{"Gamma", gamma, 3},
+		{"Hinge", Hinge[float64](), 1},
+		{"Log loss", LogLoss[float64](), 1},
+	}
+
+	const h = 1e-6
+	preds := []float64{-2.1, -0.3, 0.4, 1.1, 3.7}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, p := range preds {
+				numeric := (tt.loss.Value(tt.y, p+h) - tt.loss.Value(tt.y, p-h)) / (2 * h)
+				if d := tt.loss.Diff(tt.y, p); math.Abs(d-numeric) > 1e-4*max(1, math.Abs(numeric)) {
+					t.Errorf("Wrong derivative at %.2f : %.6f. Expected : %.6f", p, d, numeric)
+				}
+			}
+		})
+	}
+}
+
+func TestLoss_Minimum(t *testing.T) {
+	// deviances are zero when the prediction matches the target
+	if v := Poisson[float64]().Value(3, math.Log(3)); math.Abs(v) > 1e-12 {
+		t.Errorf("Poisson deviance should be 0 at mu = y, got %v", v)
+	}
+
+	tweedie, err := Tweedie(1.5)
+	if err != nil {
+		t.Errorf("Tweedie should not error : %v", err)
+		t.FailNow()
+	}
+
+	if v := tweedie.Value(3, math.Log(3)); math.Abs(v) > 1e-12 {
+		t.Errorf("Tweedie deviance should be 0 at mu = y, got %v", v)
+	}
+
+	if _, err := Tweedie(0.5); err == nil {
+		t.Error("Tweedie should error on a power below 1")
+	}
+
+	if _, err := Huber(0.0); err == nil {
+		t.Error("Huber should error on a delta which is not positive")
+	}
+
+	for _, q := range []float64{0, 1, -0.5, math.NaN()} {
+		if _, err := Quantile(q); err == nil {
+			t.Errorf("Quantile should error on q = %v", q)
+		}
+	}
+
+	if v := LogCosh[float64]().Value(2, 2); v != 0 {
+		t.Errorf("Log cosh should be 0 at y = pred, got %v", v)
+	}
+}
+
+// A row of weight 2 counts as much as the same row twice
+func TestCost_Weighted(t *testing.T) {
+	load := func(csv string, weights bool) *dataset.DataSet[float64] {
+		opts := []dataset.Option{dataset.WithTarget("y")}
+		if weights {
+			opts = append(opts, dataset.WithWeights("w"))
+		}
+
+		ds := dataset.NewDataSet[float64](0, opts...)
+		if err := ds.LoadCsvReader(strings.NewReader(csv), ','); err != nil {
+			t.Fatalf("Failed to load CSV : %v", err)
+		}
+		return &ds
+	}
+
+	// weights 1, 2 and 0.5 are the row counts 2, 4 and 1 up to a factor
+	weighted := load("x,w,y\n0,1,1\n1,2,2\n2,0.5,7\n", true)
+	repeated := load("x,y\n0,1\n0,1\n1,2\n1,2\n1,2\n1,2\n2,7\n", false)
+
+	if weighted.FeatCount() != 1 {
+		t.Errorf("The weight column should not be a feature")
+	}
+
+	var h linear_reg_hypo[float64]
+	theta := []float64{0.3, 1.7}
+	huber, _ := Huber(1.0)
+
+	for _, l := range []Loss[float64]{SquaredErr[float64](), huber} {
+		if a, b := Cost(theta, weighted, &h, l), Cost(theta, repeated, &h, l); math.Abs(a-b) > 1e-12 {
+			t.Errorf("Weighted cost %v != %v", a, b)
+		}
+
+		for j := range theta {
+			a, _ := PartialDiffCost(j, theta, weighted, &h, l)
+			b, _ := PartialDiffCost(j, theta, repeated, &h, l)
+			if math.Abs(a-b) > 1e-12 {
+				t.Errorf("Weighted partial derivative %d : %v != %v", j, a, b)
+			}
+		}
+	}
+
+	mse := func(ds *dataset.DataSet[float64]) float64 {
+		v, _ := MSE(theta, ds, func(theta []float64, x []float64) float64 { return theta[0] + theta[1]*x[0] }, 0)
+		return v
+	}
+	if a, b := mse(weighted), mse(repeated); math.Abs(a-b) > 1e-12 {
+		t.Errorf("Weighted MSE %v != %v", a, b)
+	}
+
+	// the unweighted derivative is scaled in float32
+	a, _ := PartialDiffMSE(1, theta, weighted, &h)
+	b, _ := PartialDiffMSE(1, theta, repeated, &h)
+	if math.Abs(a-b) > 1e-6 {
+		t.Errorf("Weighted MSE partial derivative %v != %v", a, b)
+	}
+
+	zero := load("x,w,y\n0,0,1\n1,0,2\n", true)
+	if _, err := MSE(theta, zero, func(theta []float64, x []float64) float64 { return theta[0] + theta[1]*x[0] }, 0); err == nil {
+		t.Error("MSE should error when the weights sum to zero")
+	}
+
+	if _, err := PartialDiffMSE(1, theta, zero, &h); err == nil {
+		t.Error("PartialDiffMSE should error when the weights sum to zero")
+	}
+}
+
+// Rows skipped by Cost do not contribute to its derivatives either
+func TestPartialDiffCost_SkipsRows(t *testing.T) {
+	load := func(csv string) *dataset.DataSet[float64] {
+		ds := dataset.NewDataSet[float64](1)
+		if err := ds.LoadCsvReader(strings.NewReader(csv), ','); err != nil {
+			t.Fatalf("Failed to load CSV : %v", err)
+		}
+		return &ds
+	}
+
+	missing := load("x,y\n0,1\n1,\n,4\n2,7\n")
+	complete := load("x,y\n0,1\n2,7\n")
+
+	var h linear_reg_hypo[float64]
+	theta := []float64{0.3, 1.7}
+	l := SquaredErr[float64]()
+
+	if a, b := Cost(theta, missing, &h, l), Cost(theta, complete, &h, l); a != b {
+		t.Errorf("Cost %v != %v", a, b)
+	}
+
+	for j := range theta {
+		a, err := PartialDiffCost(j, theta, missing, &h, l)
+		if err != nil {
+			t.Errorf("PartialDiffCost should skip the row without target : %v", err)
+		}
+
+		if b, _ := PartialDiffCost(j, theta, complete, &h, l); a != b {
+			t.Errorf("Partial derivative %d : %v != %v", j, a, b)
+		}
+	}
+
+	a, err := HessianCost(theta, missing, &h, squared_err[float64]{})
+	if err != nil {
+		t.Errorf("HessianCost should skip the rows Cost skips : %v", err)
+		t.FailNow()
+	}
+
+	b, _ := HessianCost(theta, complete, &h, squared_err[float64]{})
+	for i := range theta {
+		for j := range theta {
+			if a.At(i, j) != b.At(i, j) {
+				t.Errorf("Hessian entry <%d, %d> : %v != %v", i, j, a.At(i, j), b.At(i, j))
+			}
+		}
+	}
+}
+
+// The one pass gradient matches the per parameter one, sparse features included
+func TestLinearGradient(t *testing.T) {
+	ds := dataset.NewDataSet[float64](2)
+	if err := ds.LoadCsvReader(strings.NewReader("a,b,y\n1,0,3\n2,5,1\n0,1,-2\n4,0,0\n"), ','); err != nil {
+		t.Fatalf("Failed to load CSV : %v", err)
+	}
+
+	if err := ds.Sparsify("b"); err != nil {
+		t.Fatalf("Failed to sparsify : %v", err)
+	}
+
+	theta := []float64{0.5, -1, 2}
+	h := linear_reg_hypo[float64]{}
+	huber, _ := Huber(1.0)
+
+	for _, l := range []Loss[float64]{nil, huber, LogCosh[float64]()} {
+		grad, err := LinearGradient(theta, &ds, l)
+		if err != nil {
+			t.Errorf("LinearGradient should not error : %v", err)
+			t.FailNow()
+		}
+
+		if l == nil {
+			l = SquaredErr[float64]()
+		}
+
+		for j := range theta {
+			expected, _ := PartialDiffCost(j, theta, &ds, &h, l)
+			if math.Abs(grad[j]-expected) > 1e-12 {
+				t.Errorf("Wrong derivative %d : %v. Expected : %v", j, grad[j], expected)
+			}
+		}
+	}
+
+	if _, err := LinearGradient(theta[:2], &ds, nil); err == nil {
+		t.Error("LinearGradient should error on parameters not matching the features")
+	}
+}
+
+// Rows with an empty feature are skipped by the cost and by both gradients alike
+func TestLinearGradient_EmptyFeature(t *testing.T) {
+	load := func(csv string) *dataset.DataSet[float64] {
+		ds := dataset.NewDataSet[float64](2)
+		if err := ds.LoadCsvReader(strings.NewReader(csv), ','); err != nil {
+			t.Fatalf("Failed to load CSV : %v", err)
+		}
+		return &ds
+	}
+
+	empty := load("a,b,y\n1,0,3\n,5,1\n0,1,-2\n")
+	complete := load("a,b,y\n1,0,3\n0,1,-2\n")
+
+	theta := []float64{0.5, -1, 2}
+	h := linear_reg_hypo[float64]{}
+	l := SquaredErr[float64]()
+
+	if a, b := Cost(theta, empty, &h, l), Cost(theta, complete, &h, l); a != b {
+		t.Errorf("Cost %v != %v", a, b)
+	}
+
+	grad, err := LinearGradient(theta, empty, nil)
+	if err != nil {
+		t.Errorf("LinearGradient should skip the row with an empty feature : %v", err)
+		t.FailNow()
+	}
+
+	for j := range theta {
+		expected, err := PartialDiffCost(j, theta, complete, &h, l)
+		if err != nil {
+			t.Errorf("PartialDiffCost should not error : %v", err)
+		}
+
+		if d, _ := PartialDiffCost(j, theta, empty, &h, l); d != expected {
+			t.Errorf("PartialDiffCost %d : %v. Expected : %v", j, d, expected)
+		}
+
+		if math.Abs(grad[j]-expected) > 1e-12 {
+			t.Errorf("Wrong derivative %d : %v. Expected : %v", j, grad[j], expected)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package optimization
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/bleak-and-bare/machine_learning/internal/dataset"
@@ -28,11 +29,13 @@ type Hypothesis[T constraints.Float] interface {
 func PartialDiffMSE[T constraints.Float](j int, params []T, ds *dataset.DataSet[T], h Hypothesis[T]) (T, error) {
 	sample_size := ds.Size()
 
-	var sum T
+	var sum, total T
 	var caught_err error
 
 	for ds := range ds.Samples() {
 		y := ds.GetTarget()
+		w := ds.GetWeight()
+		total += w
 
 		if y != nil {
 			hypo, err := h.On(params, &ds)
@@ -47,7 +50,7 @@ func PartialDiffMSE[T constraints.Float](j int, params []T, ds *dataset.DataSet[
 				break
 			}
 
-			sum += diff * (hypo - *y)
+			sum += w * diff * (hypo - *y)
 		} else {
 			caught_err = fmt.Errorf("Target not found at <%d, %d>", ds.GetRow(), j-1)
 			break
@@ -58,17 +61,39 @@ func PartialDiffMSE[T constraints.Float](j int, params []T, ds *dataset.DataSet[
 		return 0.0, caught_err
 	}
 
+	if ds.Weighted() {
+		if total == 0 {
+			return 0.0, errors.New("PartialDiffMSE : total sample weight is zero")
+		}
+		return 2 * sum / total, nil
+	}
+
 	return T(2/float32(sample_size)) * sum, nil
 }
 
 /*
-Mean squared error
+Mean squared error, weighted by the sample weights of ds if any. Weights summing to zero are an error
 Parameters :
 - h : hypothesis function
 - placeholder : default value for invalid cells found in the dataset
 */
-func MSE[T constraints.Float](params []T, ds *dataset.DataSet[T], h func(params []T, sample []T) T, placeholder T) T {
+func MSE[T constraints.Float](params []T, ds *dataset.DataSet[T], h func(params []T, sample []T) T, placeholder T) (T, error) {
+	if ds.Weighted() {
+		var sum, total T
+		for s := range ds.Samples() {
+			w := s.GetWeight()
+			r := h(params, s.GetSampleTestNoErr(placeholder)) - *s.GetTarget()
+			sum += w * r * r
+			total += w
+		}
+
+		if total == 0 {
+			return 0.0, errors.New("MSE : total sample weight is zero")
+		}
+		return sum / total, nil
+	}
+
 	return accumulator.Mean(adapter.Squared(iterable.Map(ds.Samples(), func(ds dataset.DataSample[T]) T {
 		return h(params, ds.GetSampleTestNoErr(placeholder)) - *ds.GetTarget()
-	})))
+	}))), nil
 }
@@ -0,0 +1,126 @@
+package optimization
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"github.com/bleak-and-bare/machine_learning/internal/maths"
+	"golang.org/x/exp/constraints"
+)
+
+// Nelder-Mead downhill simplex. Only needs the cost function and is deterministic
+type NelderMead[T constraints.Float] struct {
+	theta     []T
+	history   []HistoryEntry[T]
+	Step      float32 // relative size of the initial simplex around the starting point
+	Bounds    Bounds[T]
+	Threshold maths.Threshold // stops when the relative cost spread of the simplex is below CostEps
+	Cost      func(theta []T, ds *dataset.DataSet[T]) T
+}
+
+func NewNelderMead[T constraints.Float](t maths.Threshold) NelderMead[T] {
+	return NelderMead[T]{
+		Step:      0.1,
+		Threshold: t,
+	}
+}
+
+func (o *NelderMead[T]) GetParams() []T {
+	return o.theta
+}
+
+func (o *NelderMead[T]) GetHistory() []HistoryEntry[T] {
+	return o.history
+}
+
+type vertex struct {
+	x []float64
+	f float64
+}
+
+func (o *NelderMead[T]) Fit(ds *dataset.DataSet[T]) error {
+	if o.Cost == nil {
+		return errors.New("No cost function supplied")
+	}
+
+	o.theta = initial_params(ds)
+	o.history = nil
+	n := len(o.theta)
+
+	if err := o.Bounds.validate(n); err != nil {
+		return err
+	}
+
+	f := objective[T]{cost: o.Cost, ds: ds}
+	eval := func(x []float64) vertex {
+		o.Bounds.clip(x)
+		return vertex{x, f.value(x)}
+	}
+
+	x0 := to_f64(o.theta)
+	simplex := []vertex{eval(x0)}
+	for i := range n {
+		x := append([]float64(nil), x0...)
+		step := float64(o.Step) * max(1, math.Abs(x[i]))
+		if o.Bounds.Upper != nil && x[i]+step > float64(o.Bounds.Upper[i]) {
+			step = -step
+		}
+		x[i] += step
+		simplex = append(simplex, eval(x))
+	}
+
+	const alpha, gamma, rho, sigma = 1.0, 2.0, 0.5, 0.5
+
+	for iter := 0; iter < o.Threshold.MaxEpochs; iter++ {
+		sort.SliceStable(simplex, func(i, j int) bool { return simplex[i].f < simplex[j].f })
+		best, worst := simplex[0], simplex[n]
+		o.history = append(o.history, HistoryEntry[T]{iter + 1, T(best.f), 0})
+
+		if iter >= o.Threshold.MinEphocs && math.Abs(worst.f-best.f)/max(1, math.Abs(best.f)) <= float64(o.Threshold.CostEps) {
+			fmt.Printf("Hitting cost breakpoint. Total iterations : %d\n", iter+1)
+			break
+		}
+
+		centroid := make([]float64, n)
+		for _, v := range simplex[:n] {
+			axpy(1/float64(n), v.x, centroid)
+		}
+
+		reflected := eval(add_scaled(centroid, alpha, sub(centroid, worst.x)))
+		switch {
+		case reflected.f < best.f:
+			expanded := eval(add_scaled(centroid, gamma, sub(reflected.x, centroid)))
+			if expanded.f < reflected.f {
+				simplex[n] = expanded
+			} else {
+				simplex[n] = reflected
+			}
+		case reflected.f < simplex[n-1].f:
+			simplex[n] = reflected
+		default:
+			var contracted vertex
+			if reflected.f < worst.f {
+				contracted = eval(add_scaled(centroid, rho, sub(reflected.x, centroid)))
+			} else {
+				contracted = eval(add_scaled(centroid, rho, sub(worst.x, centroid)))
+			}
+
+			if contracted.f < min(reflected.f, worst.f) {
+				simplex[n] = contracted
+				continue
+			}
+
+			// shrink toward the best vertex
+			for i := 1; i <= n; i++ {
+				simplex[i] = eval(add_scaled(best.x, sigma, sub(simplex[i].x, best.x)))
+			}
+		}
+	}
+
+	sort.SliceStable(simplex, func(i, j int) bool { return simplex[i].f < simplex[j].f })
+	o.theta = from_f64[T](simplex[0].x)
+	return nil
+}
@@ -0,0 +1,253 @@
+package optimization
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"github.com/bleak-and-bare/machine_learning/internal/maths"
+	"github.com/bleak-and-bare/machine_learning/internal/maths/matrix"
+	"golang.org/x/exp/constraints"
+)
+
+// Damped Newton's method. Used through UseLoss with a GLM loss, this is iteratively reweighted least squares (IRLS)
+type Newton[T constraints.Float] struct {
+	theta           []T
+	history         []HistoryEntry[T]
+	Threshold       maths.Threshold
+	Cost            func(theta []T, ds *dataset.DataSet[T]) T
+	CostPartialDiff func(j int, theta []T, ds *dataset.DataSet[T]) (T, error)
+	// Hessian of the cost. Approximated with finite differences of CostPartialDiff when nil
+	CostHessian func(theta []T, ds *dataset.DataSet[T]) (matrix.Dense[T], error)
+}
+
+func NewNewton[T constraints.Float](t maths.Threshold) Newton[T] {
+	return Newton[T]{
+		Threshold: t,
+	}
+}
+
+func (o *Newton[T]) GetParams() []T {
+	return o.theta
+}
+
+func (o *Newton[T]) GetHistory() []HistoryEntry[T] {
+	return o.history
+}
+
+// Set Cost, CostPartialDiff and, when l has a second derivative, CostHessian to minimize the mean loss l of the hypothesis h
+func (o *Newton[T]) UseLoss(h Hypothesis[T], l Loss[T]) {
+	o.Cost, o.CostPartialDiff = loss_callbacks(h, l)
+	o.CostHessian = nil
+
+	if tl, ok := l.(TwiceDiffLoss[T]); ok {
+		o.CostHessian = func(theta []T, ds *dataset.DataSet[T]) (matrix.Dense[T], error) {
+			return HessianCost(theta, ds, h, tl)
+		}
+	}
+}
+
+/*
+Gauss-Newton Hessian of Cost : weighted mean of l.SecondDiff(y, h(x)) * grad h(x) * grad h(x)^T.
+It is exact for hypotheses linear in their parameters, which makes Newton equivalent to IRLS.
+Rows skipped by Cost are skipped as well
+*/
+func HessianCost[T constraints.Float](params []T, ds *dataset.DataSet[T], h Hypothesis[T], l TwiceDiffLoss[T]) (matrix.Dense[T], error) {
+	n := len(params)
+	hess := matrix.New[T](n, n)
+	grad_h := make([]T, n)
+	var total T
+
+	for s := range ds.Samples() {
+		y := s.GetTarget()
+		if y == nil {
+			continue
+		}
+
+		pred, err := h.On(params, &s)
+		if err != nil {
+			continue
+		}
+
+		for j := range n {
+			if grad_h[j], err = h.Diff(j, params, &s); err != nil {
+				return hess, err
+			}
+		}
+
+		w := s.GetWeight() * l.SecondDiff(*y, pred)
+		for i := range n {
+			for j := i; j < n; j++ {
+				hess.Add(i, j, w*grad_h[i]*grad_h[j])
+			}
+		}
+		total += s.GetWeight()
+	}
+
+	for i := range n {
+		for j := i; j < n; j++ {
+			v := hess.At(i, j)
+			if total > 0 {
+				v /= total
+			}
+			hess.Set(i, j, v)
+			hess.Set(j, i, v)
+		}
+	}
+
+	return hess, nil
+}
+
+func (o *Newton[T]) hessian(f *objective[T], x []float64) (matrix.Dense[float64], error) {
+	n := len(x)
+	if o.CostHessian != nil {
+		h, err := o.CostHessian(from_f64[T](x), f.ds)
+		if err != nil {
+			return matrix.Dense[float64]{}, err
+		}
+
+		if h.Rows() != n || h.Cols() != n {
+			return matrix.Dense[float64]{}, errors.New("Newton : Hessian does not match the number of parameters")
+		}
+
+		r := matrix.New[float64](n, n)
+		for i := range n {
+			for j := range n {
+				r.Set(i, j, float64(h.At(i, j)))
+			}
+		}
+		return r, nil
+	}
+
+	// central differences of the gradient, symmetrized
+	r := matrix.New[float64](n, n)
+	for i := range n {
+		step := 1e-4 * max(1, math.Abs(x[i]))
+		x_p, x_m := append([]float64(nil), x...), append([]float64(nil), x...)
+		x_p[i] += step
+		x_m[i] -= step
+
+		g_p, err := f.grad(x_p)
+		if err != nil {
+			return r, err
+		}
+
+		g_m, err := f.grad(x_m)
+		if err != nil {
+			return r, err
+		}
+
+		for j := range n {
+			r.Add(i, j, (g_p[j]-g_m[j])/(4*step))
+			r.Add(j, i, (g_p[j]-g_m[j])/(4*step))
+		}
+	}
+
+	return r, nil
+}
+
+// Solve (H + lambda*I) d = -g, increasing lambda until d is a descent direction
+func newton_direction(hess matrix.Dense[float64], g []float64) []float64 {
+	n := len(g)
+	neg_g := scale(g, -1)
+
+	var diag float64
+	for i := range n {
+		diag = max(diag, math.Abs(hess.At(i, i)))
+	}
+
+	lambda := 0.0
+	for range 30 {
+		h := hess.Clone()
+		for i := range n {
+			h.Add(i, i, lambda)
+		}
+
+		if d, err := h.Solve(neg_g); err == nil && dot(d, g) < 0 {
+			return d
+		}
+
+		lambda = max(2*lambda, 1e-8*max(diag, 1))
+	}
+
+	return neg_g
+}
+
+// Backtracking along d until the Armijo condition holds. x_new is only meaningful when ok
+func armijo[T constraints.Float](f *objective[T], x []float64, fx float64, gx, d []float64) (x_new []float64, f_new float64, ok bool) {
+	step := 1.0
+	for range 50 {
+		x_new = add_scaled(x, step, d)
+		f_new = f.value(x_new)
+		if f_new <= fx+1e-4*step*dot(gx, d) {
+			return x_new, f_new, true
+		}
+		step /= 2
+	}
+
+	return x, fx, false
+}
+
+func (o *Newton[T]) Fit(ds *dataset.DataSet[T]) error {
+	if o.Cost == nil {
+		return errors.New("No cost function supplied")
+	}
+
+	if o.CostPartialDiff == nil {
+		return errors.New("No partial derivative function supplied")
+	}
+
+	o.theta = initial_params(ds)
+	o.history = nil
+	f := objective[T]{o.Cost, o.CostPartialDiff, ds}
+
+	x := to_f64(o.theta)
+	fx, gx, err := f.eval(x)
+	if err != nil {
+		return err
+	}
+
+	for iter := 0; iter < o.Threshold.MaxEpochs; iter++ {
+		hess, err := o.hessian(&f, x)
+		if err != nil {
+			return err
+		}
+
+		// falls back to a gradient step when the Newton direction does not decrease the cost enough
+		x_new, f_new, ok := armijo(&f, x, fx, gx, newton_direction(hess, gx))
+		if !ok {
+			x_new, f_new, ok = armijo(&f, x, fx, gx, scale(gx, -1))
+		}
+
+		if !ok {
+			o.theta = from_f64[T](x)
+			return fmt.Errorf("Newton.Fit : line search failed at iteration %d", iter+1)
+		}
+
+		g_new, err := f.grad(x_new)
+		if err != nil {
+			return err
+		}
+
+		prev := fx
+		x, fx, gx = x_new, f_new, g_new
+		grad_norm := norm(gx)
+		o.history = append(o.history, HistoryEntry[T]{iter + 1, T(fx), T(grad_norm)})
+
+		if iter+1 >= o.Threshold.MinEphocs {
+			if grad_norm <= float64(o.Threshold.GradEps) {
+				fmt.Printf("Hitting gradient breakpoint. Total iterations : %d\n", iter+1)
+				break
+			}
+
+			if math.Abs(fx-prev)/max(1, math.Abs(prev)) <= float64(o.Threshold.CostEps) {
+				fmt.Printf("Hitting cost breakpoint. Total iterations : %d\n", iter+1)
+				break
+			}
+		}
+	}
+
+	o.theta = from_f64[T](x)
+	return nil
+}
@@ -0,0 +1,97 @@
+package optimization
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"github.com/bleak-and-bare/machine_learning/internal/maths"
+)
+
+func TestLBFGS(t *testing.T) {
+	ds := dataset.NewDataSet[float64](1)
+	str := strings.NewReader(`x,y
+0,0
+1,1
+2,2`)
+
+	ds.LoadCsvReader(str, ',')
+	o := NewLBFGS[float64](maths.DefThreshold())
+	o.Cost = linear_reg_cost
+	o.CostPartialDiff = linear_reg_cost_partial_diff
+
+	if err := o.Fit(&ds); err != nil {
+		t.Errorf("LBFGS.Fit should not error : %v", err)
+		t.FailNow()
+	}
+
+	theta := o.GetParams()
+	if math.Abs(theta[0]) >= 1e-3 || math.Abs(theta[1]-1) >= 1e-3 {
+		t.Errorf("Wrong parameter values : [%.3f, %.3f] != [0, 1]", theta[0], theta[1])
+	}
+
+	if len(o.GetHistory()) > 50 {
+		t.Errorf("LBFGS took %d iterations on a quadratic", len(o.GetHistory()))
+	}
+}
+
+func TestNewton_IRLS(t *testing.T) {
+	ds := dataset.NewDataSet[float64](1)
+	str := strings.NewReader(`x,y
+0,1
+0,2
+1,2
+1,4
+2,6
+2,9`)
+
+	ds.LoadCsvReader(str, ',')
+
+	for _, hessian := range []string{"exact", "finite differences"} {
+		t.Run(hessian, func(t *testing.T) {
+			o := NewNewton[float64](maths.DefThreshold())
+			o.UseLoss(&linear_reg_hypo[float64]{}, Poisson[float64]())
+			if hessian != "exact" {
+				o.CostHessian = nil
+			}
+
+			if err := o.Fit(&ds); err != nil {
+				t.Errorf("Newton.Fit should not error : %v", err)
+				t.FailNow()
+			}
+
+			// Poisson regression with a log link reproduces the mean count of each group
+			theta := o.GetParams()
+			for x, mean := range []float64{1.5, 3, 7.5} {
+				if mu := math.Exp(theta[0] + theta[1]*float64(x)); math.Abs(mu-mean) > 0.3 {
+					t.Errorf("Wrong fitted mean at x = %d : %.3f. Expected about %.3f", x, mu, mean)
+				}
+			}
+
+			if len(o.GetHistory()) > 30 {
+				t.Errorf("Newton took %d iterations", len(o.GetHistory()))
+			}
+		})
+	}
+}
+
+// A gradient inconsistent with a cost minimal at the starting point makes the line search fail : the starting point is kept
+func TestNewton_LineSearchFailure(t *testing.T) {
+	ds := dataset.NewDataSet[float64](1)
+	ds.LoadCsvReader(strings.NewReader("x,y\n0,0\n1,1\n2,2"), ',')
+
+	o := NewNewton[float64](maths.DefThreshold())
+	o.Cost = func(theta []float64, ds *dataset.DataSet[float64]) float64 {
+		return math.Abs(theta[0]-1) + math.Abs(theta[1])
+	}
+	o.CostPartialDiff = func(j int, theta []float64, ds *dataset.DataSet[float64]) (float64, error) { return 1, nil }
+
+	if err := o.Fit(&ds); err == nil {
+		t.Error("Newton.Fit should error when no step satisfies the Armijo condition")
+	}
+
+	if theta := o.GetParams(); theta[0] != 1 || theta[1] != 0 {
+		t.Errorf("Parameters moved although no step was accepted : %v", theta)
+	}
+}
@@ -0,0 +1,46 @@
+// Test helpers for code built on the optimization package
+package optimtest
+
+import (
+	"testing"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"github.com/bleak-and-bare/machine_learning/internal/maths/optimization"
+	"golang.org/x/exp/constraints"
+)
+
+// Fails the test when h.Diff is more than tol away from finite differences of h.On on ds
+func AssertGradient[T constraints.Float](t testing.TB, h optimization.Hypothesis[T], ds *dataset.DataSet[T], tol float64) {
+	t.Helper()
+
+	check, err := optimization.CheckGradient(h, ds, optimization.GradientCheckOptions{Seed: 1})
+	if err != nil {
+		t.Errorf("Gradient check errored : %v", err)
+		return
+	}
+
+	report(t, check, tol)
+}
+
+// Fails the test when partial_diff is more than tol away from finite differences of cost on ds
+func AssertCostGradient[T constraints.Float](t testing.TB, cost func(theta []T, ds *dataset.DataSet[T]) T, partial_diff func(j int, theta []T, ds *dataset.DataSet[T]) (T, error), ds *dataset.DataSet[T], tol float64) {
+	t.Helper()
+
+	check, err := optimization.CheckCostGradient(cost, partial_diff, ds, optimization.GradientCheckOptions{Seed: 1})
+	if err != nil {
+		t.Errorf("Gradient check errored : %v", err)
+		return
+	}
+
+	report(t, check, tol)
+}
+
+func report(t testing.TB, check optimization.GradientCheck, tol float64) {
+	t.Helper()
+
+	for j, e := range check.MaxRelErr {
+		if e > tol {
+			t.Errorf("Wrong derivative regarding theta[%d] : relative error %.3g > %.3g", j, e, tol)
+		}
+	}
+}
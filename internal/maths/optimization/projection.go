@@ -0,0 +1,129 @@
+package optimization
+
+import (
+	"fmt"
+	"slices"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Constraint set used by projected gradient descent
+type Projection[T constraints.Float] interface {
+	// Replace theta, in place, by its closest point in the constraint set
+	Project(theta []T)
+
+	// Human readable constraints that hold with equality at theta
+	Active(theta []T) []string
+}
+
+type box[T constraints.Float] struct {
+	bounds Bounds[T]
+}
+
+// Projections that only fit a given number of parameters. GradientDescent checks them before fitting
+type sized_projection interface {
+	validate(n int) error
+}
+
+// Per parameter lower and upper bounds, bias included. Project panics when theta does not match the bounds
+func Box[T constraints.Float](b Bounds[T]) Projection[T] {
+	return &box[T]{b}
+}
+
+func (p *box[T]) validate(n int) error {
+	return p.bounds.validate(n)
+}
+
+func (p *box[T]) Project(theta []T) {
+	if err := p.bounds.validate(len(theta)); err != nil {
+		panic(fmt.Sprintf("optimization.Box : %v", err))
+	}
+
+	for i := range theta {
+		if p.bounds.Lower != nil && i < len(p.bounds.Lower) {
+			theta[i] = max(theta[i], p.bounds.Lower[i])
+		}
+		if p.bounds.Upper != nil && i < len(p.bounds.Upper) {
+			theta[i] = min(theta[i], p.bounds.Upper[i])
+		}
+	}
+}
+
+func (p *box[T]) Active(theta []T) []string {
+	var active []string
+	for i := range theta {
+		if p.bounds.Lower != nil && i < len(p.bounds.Lower) && theta[i] == p.bounds.Lower[i] {
+			active = append(active, fmt.Sprintf("theta[%d] >= %v", i, p.bounds.Lower[i]))
+		}
+		if p.bounds.Upper != nil && i < len(p.bounds.Upper) && theta[i] == p.bounds.Upper[i] {
+			active = append(active, fmt.Sprintf("theta[%d] <= %v", i, p.bounds.Upper[i]))
+		}
+	}
+	return active
+}
+
+type non_negative[T constraints.Float] struct{}
+
+// Non negative coefficients. The bias theta[0] is left free
+func NonNegative[T constraints.Float]() Projection[T] {
+	return non_negative[T]{}
+}
+
+func (non_negative[T]) Project(theta []T) {
+	for i := 1; i < len(theta); i++ {
+		theta[i] = max(theta[i], 0)
+	}
+}
+
+func (non_negative[T]) Active(theta []T) []string {
+	var active []string
+	for i := 1; i < len(theta); i++ {
+		if theta[i] == 0 {
+			active = append(active, fmt.Sprintf("theta[%d] >= 0", i))
+		}
+	}
+	return active
+}
+
+type simplex[T constraints.Float] struct {
+	sum T
+}
+
+// Non negative coefficients summing to sum. The bias theta[0] is left free
+func Simplex[T constraints.Float](sum T) Projection[T] {
+	return simplex[T]{sum}
+}
+
+// Euclidean projection onto the simplex (Duchi et al. 2008)
+func (p simplex[T]) Project(theta []T) {
+	if len(theta) < 2 {
+		return
+	}
+
+	w := theta[1:]
+	sorted := slices.Clone(w)
+	slices.Sort(sorted)
+	slices.Reverse(sorted)
+
+	var cumul, tau T
+	for k, u := range sorted {
+		cumul += u
+		if t := (cumul - p.sum) / T(k+1); u-t > 0 {
+			tau = t
+		}
+	}
+
+	for i := range w {
+		w[i] = max(w[i]-tau, 0)
+	}
+}
+
+func (p simplex[T]) Active(theta []T) []string {
+	active := []string{fmt.Sprintf("sum(theta[1:]) = %v", p.sum)}
+	for i := 1; i < len(theta); i++ {
+		if theta[i] == 0 {
+			active = append(active, fmt.Sprintf("theta[%d] >= 0", i))
+		}
+	}
+	return active
+}
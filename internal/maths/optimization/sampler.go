@@ -0,0 +1,120 @@
+package optimization
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sort"
+)
+
+/*
+Draws the mini-batches of an epoch as row indices, leaving the dataset untouched.
+Without Replacement every row with a positive weight is drawn exactly once per epoch : weights then only decide
+the order of the rows, so they should be paired with Replacement to change how often a row is seen
+*/
+type Sampler struct {
+	Replacement bool      // draw rows with replacement
+	DropLast    bool      // skip the last batch when it is smaller than the batch size
+	Weights     []float64 // sampling weight of each row. Uniform when nil, rows with a zero weight are never drawn
+}
+
+// Returns the row indices of every batch of one epoch over n rows
+func (s *Sampler) Batches(n, batch_size int, r *rand.Rand) ([][]int, error) {
+	if batch_size <= 0 {
+		return nil, errors.New("Sampler.Batches : batch size must be positive")
+	}
+
+	if s.Weights != nil && len(s.Weights) != n {
+		return nil, errors.New("Sampler.Batches : weights do not match the number of rows")
+	}
+
+	var order []int
+	var err error
+	if s.Replacement {
+		order, err = s.draw_with_replacement(n, r)
+	} else {
+		order, err = s.permutation(n, r)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var batches [][]int
+	for start := 0; start < len(order); start += batch_size {
+		end := min(start+batch_size, len(order))
+		if end-start < batch_size && s.DropLast {
+			break
+		}
+		batches = append(batches, order[start:end])
+	}
+
+	return batches, nil
+}
+
+func (s *Sampler) permutation(n int, r *rand.Rand) ([]int, error) {
+	if s.Weights == nil {
+		return r.Perm(n), nil
+	}
+
+	// Efraimidis-Spirakis : sorting by Exp(1)/w gives a weighted permutation
+	keys := make([]float64, 0, n)
+	order := make([]int, 0, n)
+	for i, w := range s.Weights {
+		if w < 0 || math.IsNaN(w) {
+			return nil, errors.New("Sampler.Batches : weights must be non negative")
+		}
+
+		if w > 0 {
+			keys = append(keys, r.ExpFloat64()/w)
+			order = append(order, i)
+		}
+	}
+
+	sort.Sort(by_key{order, keys})
+	return order, nil
+}
+
+func (s *Sampler) draw_with_replacement(n int, r *rand.Rand) ([]int, error) {
+	order := make([]int, n)
+	if s.Weights == nil {
+		for i := range order {
+			order[i] = r.IntN(n)
+		}
+		return order, nil
+	}
+
+	cumul := make([]float64, n)
+	var total float64
+	for i, w := range s.Weights {
+		if w < 0 || math.IsNaN(w) {
+			return nil, errors.New("Sampler.Batches : weights must be non negative")
+		}
+		total += w
+		cumul[i] = total
+	}
+
+	if total == 0 {
+		return nil, errors.New("Sampler.Batches : every weight is zero")
+	}
+
+	for i := range order {
+		u := r.Float64() * total
+		// first row whose cumulated weight is strictly above u, which skips zero weights
+		order[i] = min(n-1, sort.Search(n, func(k int) bool { return cumul[k] > u }))
+	}
+
+	return order, nil
+}
+
+type by_key struct {
+	idx  []int
+	keys []float64
+}
+
+func (b by_key) Len() int           { return len(b.idx) }
+func (b by_key) Less(i, j int) bool { return b.keys[i] < b.keys[j] }
+func (b by_key) Swap(i, j int) {
+	b.idx[i], b.idx[j] = b.idx[j], b.idx[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
@@ -0,0 +1,138 @@
+package sparse
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/constraints"
+)
+
+/*
+Compressed sparse column matrix. The non zero entries of the j-th column are Values[Indptr[j]:Indptr[j+1]],
+their row being the matching entry of Indices, sorted increasingly
+*/
+type CSC[T constraints.Float] struct {
+	rows    int
+	cols    int
+	Indptr  []int
+	Indices []int
+	Values  []T
+}
+
+func (m *CSC[T]) Rows() int {
+	return m.rows
+}
+
+func (m *CSC[T]) Cols() int {
+	return m.cols
+}
+
+func (m *CSC[T]) NNZ() int {
+	return len(m.Values)
+}
+
+// Non zero entries of the j-th column, sharing memory with the matrix
+func (m *CSC[T]) Col(j int) ([]int, []T) {
+	return m.Indices[m.Indptr[j]:m.Indptr[j+1]], m.Values[m.Indptr[j]:m.Indptr[j+1]]
+}
+
+func (m *CSC[T]) At(i, j int) T {
+	rows, values := m.Col(j)
+	for k, r := range rows {
+		if r == i {
+			return values[k]
+		}
+	}
+	return 0
+}
+
+// Same matrix stored by columns, in O(nnz)
+func (m *CSR[T]) ToCSC() CSC[T] {
+	c := CSC[T]{
+		rows:    m.rows,
+		cols:    m.cols,
+		Indptr:  make([]int, m.cols+1),
+		Indices: make([]int, m.NNZ()),
+		Values:  make([]T, m.NNZ()),
+	}
+
+	for _, j := range m.Indices {
+		c.Indptr[j+1]++
+	}
+	for j := range m.cols {
+		c.Indptr[j+1] += c.Indptr[j]
+	}
+
+	next := append([]int(nil), c.Indptr[:m.cols]...)
+	for i := range m.rows {
+		indices, values := m.Row(i)
+		for k, j := range indices {
+			c.Indices[next[j]] = i
+			c.Values[next[j]] = values[k]
+			next[j]++
+		}
+	}
+
+	return c
+}
+
+// Same matrix stored by rows, in O(nnz)
+func (m *CSC[T]) ToCSR() CSR[T] {
+	r := CSR[T]{
+		rows:    m.rows,
+		cols:    m.cols,
+		Indptr:  make([]int, m.rows+1),
+		Indices: make([]int, m.NNZ()),
+		Values:  make([]T, m.NNZ()),
+	}
+
+	for _, i := range m.Indices {
+		r.Indptr[i+1]++
+	}
+	for i := range m.rows {
+		r.Indptr[i+1] += r.Indptr[i]
+	}
+
+	next := append([]int(nil), r.Indptr[:m.rows]...)
+	for j := range m.cols {
+		rows, values := m.Col(j)
+		for k, i := range rows {
+			r.Indices[next[i]] = j
+			r.Values[next[i]] = values[k]
+			next[i]++
+		}
+	}
+
+	return r
+}
+
+// m * v, in O(nnz)
+func (m *CSR[T]) MulVec(v []T) ([]T, error) {
+	if len(v) != m.cols {
+		return nil, fmt.Errorf("CSR.MulVec : vector of length %d for %d columns", len(v), m.cols)
+	}
+
+	res := make([]T, m.rows)
+	for i := range m.rows {
+		indices, values := m.Row(i)
+		for k, j := range indices {
+			res[i] += values[k] * v[j]
+		}
+	}
+	return res, nil
+}
+
+// transpose(m) * v, in O(nnz). Gradients of linear models are of this form
+func (m *CSC[T]) TMulVec(v []T) ([]T, error) {
+	if len(v) != m.rows {
+		return nil, fmt.Errorf("CSC.TMulVec : vector of length %d for %d rows", len(v), m.rows)
+	}
+
+	res := make([]T, m.cols)
+	for j := range m.cols {
+		rows, values := m.Col(j)
+		for k, i := range rows {
+			res[j] += values[k] * v[i]
+		}
+	}
+	return res, nil
+}
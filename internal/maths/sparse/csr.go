@@ -0,0 +1,175 @@
+package sparse
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"golang.org/x/exp/constraints"
+)
+
+/*
+Compressed sparse row matrix. The non zero entries of the i-th row are Values[Indptr[i]:Indptr[i+1]],
+their column being the matching entry of Indices, sorted increasingly
+*/
+type CSR[T constraints.Float] struct {
+	rows    int
+	cols    int
+	Indptr  []int
+	Indices []int
+	Values  []T
+}
+
+// Empty matrix of cols columns, filled with AppendRow
+func NewCSR[T constraints.Float](cols int) CSR[T] {
+	return CSR[T]{
+		cols:   cols,
+		Indptr: []int{0},
+	}
+}
+
+// Matrix of the given storage arrays, which are checked and not copied
+func FromParts[T constraints.Float](rows, cols int, indptr, indices []int, values []T) (CSR[T], error) {
+	if rows < 0 || cols < 0 || len(indptr) != rows+1 || indptr[0] != 0 || indptr[rows] != len(indices) || len(indices) != len(values) {
+		return CSR[T]{}, errors.New("sparse.FromParts : inconsistent storage")
+	}
+
+	for i := range rows {
+		if indptr[i] > indptr[i+1] {
+			return CSR[T]{}, errors.New("sparse.FromParts : decreasing row pointers")
+		}
+
+		for k := indptr[i]; k < indptr[i+1]; k++ {
+			if indices[k] < 0 || indices[k] >= cols || (k > indptr[i] && indices[k] <= indices[k-1]) {
+				return CSR[T]{}, fmt.Errorf("sparse.FromParts : invalid column at row %d", i)
+			}
+		}
+	}
+
+	return CSR[T]{rows, cols, indptr, indices, values}, nil
+}
+
+func (m *CSR[T]) Rows() int {
+	return m.rows
+}
+
+func (m *CSR[T]) Cols() int {
+	return m.cols
+}
+
+// Count of stored entries
+func (m *CSR[T]) NNZ() int {
+	return len(m.Values)
+}
+
+/*
+Append a row from its non zero entries. Indices need not be sorted and zero values are not stored,
+a repeated index keeps the sum of its values
+*/
+func (m *CSR[T]) AppendRow(indices []int, values []T) error {
+	if len(indices) != len(values) {
+		return fmt.Errorf("CSR.AppendRow : %d indices for %d values", len(indices), len(values))
+	}
+
+	order := make([]int, len(indices))
+	for k, j := range indices {
+		if j < 0 || j >= m.cols {
+			return fmt.Errorf("CSR.AppendRow : column %d out of range", j)
+		}
+		order[k] = k
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return indices[a] - indices[b]
+	})
+
+	start := len(m.Indices)
+	for _, k := range order {
+		if n := len(m.Indices); n > start && m.Indices[n-1] == indices[k] {
+			m.Values[n-1] += values[k]
+			continue
+		}
+		m.Indices = append(m.Indices, indices[k])
+		m.Values = append(m.Values, values[k])
+	}
+
+	// drop the zeros, including sums cancelling out
+	kept := start
+	for k := start; k < len(m.Indices); k++ {
+		if m.Values[k] != 0 {
+			m.Indices[kept], m.Values[kept] = m.Indices[k], m.Values[k]
+			kept++
+		}
+	}
+	m.Indices, m.Values = m.Indices[:kept], m.Values[:kept]
+
+	m.Indptr = append(m.Indptr, len(m.Indices))
+	m.rows++
+	return nil
+}
+
+// Non zero entries of the i-th row, sharing memory with the matrix
+func (m *CSR[T]) Row(i int) ([]int, []T) {
+	return m.Indices[m.Indptr[i]:m.Indptr[i+1]], m.Values[m.Indptr[i]:m.Indptr[i+1]]
+}
+
+// Pointer to the stored entry at <i, j>, nil when it is zero
+func (m *CSR[T]) Ref(i, j int) *T {
+	indices, values := m.Row(i)
+	if k, found := slices.BinarySearch(indices, j); found {
+		return &values[k]
+	}
+	return nil
+}
+
+func (m *CSR[T]) At(i, j int) T {
+	if v := m.Ref(i, j); v != nil {
+		return *v
+	}
+	return 0
+}
+
+func (m *CSR[T]) Clone() CSR[T] {
+	c := *m
+	c.Indptr = slices.Clone(m.Indptr)
+	c.Indices = slices.Clone(m.Indices)
+	c.Values = slices.Clone(m.Values)
+	return c
+}
+
+// Matrix made of the given rows, in the given order. Rows may repeat
+func (m *CSR[T]) SelectRows(rows []int) CSR[T] {
+	s := NewCSR[T](m.cols)
+	for _, i := range rows {
+		indices, values := m.Row(i)
+		s.Indices = append(s.Indices, indices...)
+		s.Values = append(s.Values, values...)
+		s.Indptr = append(s.Indptr, len(s.Indices))
+	}
+	s.rows = len(rows)
+	return s
+}
+
+// Columns of m followed by the columns of o. Both must have the same row count
+func HStack[T constraints.Float](m, o *CSR[T]) (CSR[T], error) {
+	if m.rows != o.rows {
+		return CSR[T]{}, fmt.Errorf("sparse.HStack : %d rows stacked with %d rows", m.rows, o.rows)
+	}
+
+	s := NewCSR[T](m.cols + o.cols)
+	s.Indices = make([]int, 0, m.NNZ()+o.NNZ())
+	s.Values = make([]T, 0, m.NNZ()+o.NNZ())
+	for i := range m.rows {
+		indices, values := m.Row(i)
+		s.Indices = append(s.Indices, indices...)
+		s.Values = append(s.Values, values...)
+
+		indices, values = o.Row(i)
+		for k, j := range indices {
+			s.Indices = append(s.Indices, m.cols+j)
+			s.Values = append(s.Values, values[k])
+		}
+		s.Indptr = append(s.Indptr, len(s.Indices))
+	}
+	s.rows = m.rows
+	return s, nil
+}
@@ -0,0 +1,89 @@
+package sparse
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCSR_AppendRow(t *testing.T) {
+	m := NewCSR[float64](4)
+	if err := m.AppendRow([]int{3, 0, 3, 1, 2}, []float64{1, 2, 1, 0, 5}); err != nil {
+		t.Errorf("CSR.AppendRow should not error : %v", err)
+		t.FailNow()
+	}
+	m.AppendRow(nil, nil)
+	m.AppendRow([]int{1, 1}, []float64{1, -1})
+
+	if err := m.AppendRow([]int{4}, []float64{1}); err == nil {
+		t.Error("Should not append an out of range column")
+	}
+
+	if m.Rows() != 3 || m.NNZ() != 3 {
+		t.Errorf("Wrong shape : %d rows, %d entries", m.Rows(), m.NNZ())
+	}
+
+	indices, values := m.Row(0)
+	if !slices.Equal(indices, []int{0, 2, 3}) || !slices.Equal(values, []float64{2, 5, 2}) {
+		t.Errorf("Wrong row : %v, %v", indices, values)
+	}
+
+	if m.At(0, 3) != 2 || m.At(0, 1) != 0 || m.Ref(2, 1) != nil {
+		t.Error("Wrong entries")
+	}
+}
+
+func TestCSR_Stack(t *testing.T) {
+	m := NewCSR[float64](2)
+	m.AppendRow([]int{0}, []float64{1})
+	m.AppendRow([]int{1}, []float64{2})
+
+	o := NewCSR[float64](3)
+	o.AppendRow([]int{2}, []float64{3})
+	o.AppendRow(nil, nil)
+
+	s, err := HStack(&m, &o)
+	if err != nil {
+		t.Errorf("HStack should not error : %v", err)
+		t.FailNow()
+	}
+
+	if s.Cols() != 5 || s.At(0, 4) != 3 || s.At(1, 1) != 2 || s.NNZ() != 3 {
+		t.Errorf("Wrong stacked matrix : %+v", s)
+	}
+
+	r := s.SelectRows([]int{1, 1, 0})
+	if r.Rows() != 3 || r.At(0, 1) != 2 || r.At(1, 1) != 2 || r.At(2, 0) != 1 {
+		t.Errorf("Wrong selected rows : %+v", r)
+	}
+}
+
+func TestCSR_ToCSC(t *testing.T) {
+	m := NewCSR[float64](3)
+	m.AppendRow([]int{0, 2}, []float64{1, 2})
+	m.AppendRow(nil, nil)
+	m.AppendRow([]int{1, 2}, []float64{3, 4})
+
+	c := m.ToCSC()
+	rows, values := c.Col(2)
+	if !slices.Equal(rows, []int{0, 2}) || !slices.Equal(values, []float64{2, 4}) || c.At(2, 1) != 3 {
+		t.Errorf("Wrong columns : %+v", c)
+	}
+
+	if back := c.ToCSR(); !slices.Equal(back.Indptr, m.Indptr) || !slices.Equal(back.Indices, m.Indices) || !slices.Equal(back.Values, m.Values) {
+		t.Errorf("Round trip should give the same matrix : %+v", back)
+	}
+
+	mv, _ := m.MulVec([]float64{1, 1, 1})
+	if !slices.Equal(mv, []float64{3, 0, 7}) {
+		t.Errorf("Wrong product : %v", mv)
+	}
+
+	tmv, _ := c.TMulVec([]float64{1, 5, 2})
+	if !slices.Equal(tmv, []float64{1, 6, 10}) {
+		t.Errorf("Wrong transposed product : %v", tmv)
+	}
+
+	if _, err := c.TMulVec([]float64{1}); err == nil {
+		t.Error("Should reject a vector of the wrong length")
+	}
+}
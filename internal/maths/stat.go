@@ -43,3 +43,26 @@ func Variance[T Number](iter iter.Seq[T]) T {
 func Stdev[T Number](iter iter.Seq[T]) T {
 	return T(math.Sqrt(float64(Variance(iter))))
 }
+
+// Weighted population variance of (value, weight) pairs, with West's update. Returns the variance, the mean and the total weight.
+// Pairs with a non positive weight are skipped
+func WeightedWelfordVar[T Number](iter iter.Seq2[T, T]) (T, T, T) {
+	var total, mean, M2 T
+
+	for v, w := range iter {
+		if w <= 0 {
+			continue
+		}
+
+		total += w
+		diff := v - mean
+		mean += diff * w / total
+		M2 += w * diff * (v - mean)
+	}
+
+	if total == 0 {
+		return 0.0, 0.0, 0.0
+	}
+
+	return M2 / total, mean, total
+}
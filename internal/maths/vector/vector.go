@@ -59,3 +59,28 @@ func Sum[T Number](v, w iter.Seq[T]) iter.Seq[T] {
 		return v + w
 	})
 }
+
+// Returns an iterator over the pairs of components of the two vectors.
+// Panics if the two iterables do not have the same length
+func Zip[T Number](v, w iter.Seq[T]) iter.Seq2[T, T] {
+	return func(yield func(T, T) bool) {
+		v_next, v_stop := iter.Pull(v)
+		w_next, w_stop := iter.Pull(w)
+
+		defer v_stop()
+		defer w_stop()
+
+		for {
+			v, v_ok := v_next()
+			w, w_ok := w_next()
+
+			if v_ok != w_ok {
+				panic("vector.Zip : Vectors do not have the same length")
+			}
+
+			if !v_ok || !yield(v, w) {
+				break
+			}
+		}
+	}
+}
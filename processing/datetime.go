@@ -0,0 +1,168 @@
+package processing
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"golang.org/x/exp/constraints"
+)
+
+type DateTimeFeature int
+
+const (
+	Year DateTimeFeature = iota
+	Month
+	DayOfWeek // 0 for sunday
+	Hour
+	IsWeekend
+	Elapsed         // seconds since the reference time
+	CyclicMonth     // sin and cos of the month on a yearly cycle
+	CyclicDayOfWeek // sin and cos of the day of week on a weekly cycle
+	CyclicHour      // sin and cos of the time of day on a daily cycle
+)
+
+var datetime_suffixes = [][]string{
+	{"year"}, {"month"}, {"day_of_week"}, {"hour"}, {"is_weekend"}, {"elapsed"},
+	{"month_sin", "month_cos"}, {"day_of_week_sin", "day_of_week_cos"}, {"hour_sin", "hour_cos"},
+}
+
+/*
+Extracts calendar features of a time column (see dataset.TimeDataCell) into real columns named "<Column>_<feature>", like "date_month".
+Rows with an empty or non time cell get empty features. The time column itself is kept, drop it before fitting a model
+*/
+type DateTimeFeatures[T constraints.Float] struct {
+	Column    string
+	Features  []DateTimeFeature // every feature when empty
+	Reference time.Time         // origin of Elapsed. Fit sets it to the earliest time when zero
+}
+
+func (d *DateTimeFeatures[T]) features() []DateTimeFeature {
+	if len(d.Features) == 0 {
+		return []DateTimeFeature{Year, Month, DayOfWeek, Hour, IsWeekend, Elapsed, CyclicMonth, CyclicDayOfWeek, CyclicHour}
+	}
+	return d.Features
+}
+
+func (d *DateTimeFeatures[T]) Fit(ds *dataset.DataSet[T]) error {
+	if ds.ColumnIndex(d.Column) == -1 {
+		return fmt.Errorf("DateTimeFeatures.Fit : column %q not found", d.Column)
+	}
+
+	for _, f := range d.features() {
+		if f < Year || f > CyclicHour {
+			return fmt.Errorf("DateTimeFeatures.Fit : unknown feature %d", f)
+		}
+	}
+
+	if !d.Reference.IsZero() {
+		return nil
+	}
+
+	for c := range ds.Column(d.Column) {
+		if t, ok := c.(*dataset.TimeDataCell); ok && (d.Reference.IsZero() || t.Value.Before(d.Reference)) {
+			d.Reference = t.Value
+		}
+	}
+
+	if d.Reference.IsZero() {
+		return fmt.Errorf("DateTimeFeatures.Fit : column %q holds no time", d.Column)
+	}
+
+	return nil
+}
+
+// Name of the generated columns, in the order they are appended
+func (d *DateTimeFeatures[T]) FeatureNames() []string {
+	var names []string
+	for _, f := range d.features() {
+		for _, suffix := range datetime_suffixes[f] {
+			names = append(names, d.Column+"_"+suffix)
+		}
+	}
+	return names
+}
+
+func cyclic(v, period float64) (float64, float64) {
+	angle := 2 * math.Pi * v / period
+	return math.Sin(angle), math.Cos(angle)
+}
+
+func (d *DateTimeFeatures[T]) values(t time.Time) []float64 {
+	var values []float64
+	for _, f := range d.features() {
+		switch f {
+		case Year:
+			values = append(values, float64(t.Year()))
+		case Month:
+			values = append(values, float64(t.Month()))
+		case DayOfWeek:
+			values = append(values, float64(t.Weekday()))
+		case Hour:
+			values = append(values, float64(t.Hour()))
+		case IsWeekend:
+			weekend := 0.0
+			if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+				weekend = 1
+			}
+			values = append(values, weekend)
+		case Elapsed:
+			values = append(values, t.Sub(d.Reference).Seconds())
+		case CyclicMonth:
+			sin, cos := cyclic(float64(t.Month()-1), 12)
+			values = append(values, sin, cos)
+		case CyclicDayOfWeek:
+			sin, cos := cyclic(float64(t.Weekday()), 7)
+			values = append(values, sin, cos)
+		case CyclicHour:
+			day := float64(t.Hour()) + float64(t.Minute())/60 + float64(t.Second())/3600
+			sin, cos := cyclic(day, 24)
+			values = append(values, sin, cos)
+		}
+	}
+	return values
+}
+
+// Append the generated columns, the dataset being left untouched when one of their names is already taken
+func (d *DateTimeFeatures[T]) Transform(ds *dataset.DataSet[T]) error {
+	j := ds.ColumnIndex(d.Column)
+	if j == -1 {
+		return fmt.Errorf("DateTimeFeatures.Transform : column %q not found", d.Column)
+	}
+
+	if d.Reference.IsZero() && slices.Contains(d.features(), Elapsed) {
+		return errors.New("DateTimeFeatures.Transform : no reference time, call Fit first")
+	}
+
+	names := d.FeatureNames()
+	columns := make([][]dataset.DataCell, len(names))
+	for k := range columns {
+		columns[k] = make([]dataset.DataCell, 0, ds.Size())
+	}
+
+	for s := range ds.Samples() {
+		t, ok := s.At(j).(*dataset.TimeDataCell)
+		if !ok {
+			for k := range columns {
+				columns[k] = append(columns[k], nil)
+			}
+			continue
+		}
+
+		for k, v := range d.values(t.Value) {
+			columns[k] = append(columns[k], &dataset.RealDataCell[T]{Value: T(v)})
+		}
+	}
+
+	return ds.AddColumns(names, columns)
+}
+
+func (d *DateTimeFeatures[T]) FitTransform(ds *dataset.DataSet[T]) error {
+	if err := d.Fit(ds); err != nil {
+		return err
+	}
+	return d.Transform(ds)
+}
@@ -0,0 +1,67 @@
+package processing
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+)
+
+func TestDateTimeFeatures_FitTransform(t *testing.T) {
+	ds := dataset.NewDataSet[float64](0, dataset.WithTarget("y"), dataset.WithTimeLayouts(time.DateTime, time.DateOnly))
+	str := strings.NewReader(`date,y
+2024-03-02 18:00:00,1
+2024-03-04,2
+,3`)
+
+	if err := ds.LoadCsvReader(str, ','); err != nil {
+		t.Fatalf("Failed to load CSV : %v", err)
+	}
+
+	d := DateTimeFeatures[float64]{Column: "date", Features: []DateTimeFeature{Year, DayOfWeek, IsWeekend, Elapsed, CyclicHour}}
+	if err := d.FitTransform(&ds); err != nil {
+		t.Errorf("DateTimeFeatures.FitTransform should not error : %v", err)
+		t.FailNow()
+	}
+
+	if cols := ds.GetColumnNames(); !slices.Equal(cols, []string{"date", "y", "date_year", "date_day_of_week", "date_is_weekend", "date_elapsed", "date_hour_sin", "date_hour_cos"}) {
+		t.Errorf("Wrong generated columns : %v", cols)
+	}
+
+	expected := [][]float64{
+		{2024, 6, 1, 0, -1, 0},
+		{2024, 1, 0, 30 * 3600, 0, 1},
+	}
+
+	for s := range ds.Samples() {
+		if s.GetRow() == 2 {
+			if s.GetFeat(1) != nil {
+				t.Error("Rows without time should get empty features")
+			}
+			continue
+		}
+
+		for k, v := range expected[s.GetRow()] {
+			if f := s.GetFeat(k + 1); f == nil || math.Abs(*f-v) > 1e-9 {
+				t.Errorf("Wrong feature %d at row %d : expected %v", k, s.GetRow(), v)
+			}
+		}
+	}
+	// "date_month" is already taken : "date_year" is not added either
+	clash := dataset.NewDataSet[float64](0, dataset.WithTarget("y"), dataset.WithTimeLayouts(time.DateOnly))
+	if err := clash.LoadCsvReader(strings.NewReader("date,date_month,y\n2024-03-04,3,1\n"), ','); err != nil {
+		t.Fatalf("Failed to load CSV : %v", err)
+	}
+
+	d = DateTimeFeatures[float64]{Column: "date", Features: []DateTimeFeature{Year, Month}}
+	if err := d.FitTransform(&clash); err == nil {
+		t.Error("DateTimeFeatures.FitTransform should error when a generated name is taken")
+	}
+
+	if cols := clash.GetColumnNames(); !slices.Equal(cols, []string{"date", "date_month", "y"}) {
+		t.Errorf("Dataset should be left untouched : %v", cols)
+	}
+}
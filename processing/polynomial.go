@@ -0,0 +1,163 @@
+package processing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"golang.org/x/exp/constraints"
+)
+
+/*
+Generates every monomial of the selected columns up to Degree and appends them to the dataset.
+Degree one terms are the columns themselves and are not duplicated.
+Generated columns are named after their factors, like "Hours Studied^2" or "Hours Studied*Sleep Hours"
+*/
+type PolynomialFeatures[T constraints.Float] struct {
+	Columns         []string
+	Degree          int
+	InteractionOnly bool // only products of distinct columns, no powers
+	IncludeBias     bool // also append a constant column named "1"
+	terms           [][]int
+}
+
+// Builds the list of generated terms. Columns must exist in ds
+func (p *PolynomialFeatures[T]) Fit(ds *dataset.DataSet[T]) error {
+	if p.Degree < 1 {
+		return errors.New("PolynomialFeatures.Fit : degree must be at least 1")
+	}
+
+	for _, col := range p.Columns {
+		if ds.ColumnIndex(col) == -1 {
+			return fmt.Errorf("PolynomialFeatures.Fit : column %q not found", col)
+		}
+	}
+
+	p.terms = nil
+	for d := 2; d <= p.Degree; d++ {
+		p.combinations(make([]int, 0, d), 0, d)
+	}
+
+	return nil
+}
+
+// Enumerate the non decreasing index lists of length d, strictly increasing with InteractionOnly
+func (p *PolynomialFeatures[T]) combinations(prefix []int, from, d int) {
+	if len(prefix) == d {
+		p.terms = append(p.terms, append([]int(nil), prefix...))
+		return
+	}
+
+	for i := from; i < len(p.Columns); i++ {
+		next := i
+		if p.InteractionOnly {
+			next = i + 1
+		}
+		p.combinations(append(prefix, i), next, d)
+	}
+}
+
+// Name of the generated columns, in the order they are appended
+func (p *PolynomialFeatures[T]) FeatureNames() []string {
+	var names []string
+	if p.IncludeBias {
+		names = append(names, "1")
+	}
+
+	for _, term := range p.terms {
+		var factors []string
+		for k := 0; k < len(term); {
+			power := 1
+			for k+power < len(term) && term[k+power] == term[k] {
+				power++
+			}
+
+			if power > 1 {
+				factors = append(factors, fmt.Sprintf("%s^%d", p.Columns[term[k]], power))
+			} else {
+				factors = append(factors, p.Columns[term[k]])
+			}
+			k += power
+		}
+		names = append(names, strings.Join(factors, "*"))
+	}
+
+	return names
+}
+
+// Compute the generated columns, one slice of cells per name of FeatureNames.
+// A product with an empty or non real factor is left empty
+func (p *PolynomialFeatures[T]) Generate(ds *dataset.DataSet[T]) ([][]dataset.DataCell, error) {
+	indices := make([]int, len(p.Columns))
+	for i, col := range p.Columns {
+		if indices[i] = ds.ColumnIndex(col); indices[i] == -1 {
+			return nil, fmt.Errorf("PolynomialFeatures.Generate : column %q not found", col)
+		}
+	}
+
+	columns := make([][]dataset.DataCell, len(p.terms))
+	if p.IncludeBias {
+		columns = append(columns, nil)
+	}
+	for k := range columns {
+		columns[k] = make([]dataset.DataCell, 0, ds.Size())
+	}
+
+	values := make([]*T, len(indices))
+	for s := range ds.Samples() {
+		for i, j := range indices {
+			values[i] = nil
+			if c, ok := s.At(j).(*dataset.RealDataCell[T]); ok {
+				values[i] = &c.Value
+			}
+		}
+
+		k := 0
+		if p.IncludeBias {
+			columns[k] = append(columns[k], &dataset.RealDataCell[T]{Value: 1})
+			k++
+		}
+
+		for _, term := range p.terms {
+			var cell dataset.DataCell
+			if !has_nil(values, term) {
+				prod := T(1)
+				for _, i := range term {
+					prod *= *values[i]
+				}
+				cell = &dataset.RealDataCell[T]{Value: prod}
+			}
+			columns[k] = append(columns[k], cell)
+			k++
+		}
+	}
+
+	return columns, nil
+}
+
+// Append the generated columns to the dataset, which is left untouched when one of their names is already taken
+func (p *PolynomialFeatures[T]) Transform(ds *dataset.DataSet[T]) error {
+	columns, err := p.Generate(ds)
+	if err != nil {
+		return err
+	}
+
+	return ds.AddColumns(p.FeatureNames(), columns)
+}
+
+func (p *PolynomialFeatures[T]) FitTransform(ds *dataset.DataSet[T]) error {
+	if err := p.Fit(ds); err != nil {
+		return err
+	}
+	return p.Transform(ds)
+}
+
+func has_nil[T any](values []*T, term []int) bool {
+	for _, i := range term {
+		if values[i] == nil {
+			return true
+		}
+	}
+	return false
+}
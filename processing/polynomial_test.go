@@ -0,0 +1,125 @@
+package processing
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+)
+
+func TestPolynomialFeatures_Transform(t *testing.T) {
+	tests := []struct {
+		name        string
+		poly        PolynomialFeatures[float32]
+		columns     []string
+		first_row   []float32
+		feat_counts int
+	}{
+		{
+			"Degree 2",
+			PolynomialFeatures[float32]{Columns: []string{"a", "b"}, Degree: 2},
+			[]string{"a", "b", "y", "a^2", "a*b", "b^2"},
+			[]float32{2, 3, 4, 6, 9},
+			5,
+		}, {
+			"Interaction only",
+			PolynomialFeatures[float32]{Columns: []string{"a", "b"}, Degree: 3, InteractionOnly: true},
+			[]string{"a", "b", "y", "a*b"},
+			[]float32{2, 3, 6},
+			3,
+		}, {
+			"Degree 3 with bias",
+			PolynomialFeatures[float32]{Columns: []string{"a"}, Degree: 3, IncludeBias: true},
+			[]string{"a", "b", "y", "1", "a^2", "a^3"},
+			[]float32{2, 3, 1, 4, 8},
+			5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := dataset.NewDataSet[float32](2)
+			ds.LoadCsvReader(strings.NewReader("a,b,y\n2,3,1\n1,,0\n"), ',')
+
+			if err := tt.poly.FitTransform(&ds); err != nil {
+				t.Errorf("PolynomialFeatures.FitTransform should not error : %v", err)
+				t.FailNow()
+			}
+
+			if cols := ds.GetColumnNames(); !slices.Equal(cols, tt.columns) {
+				t.Errorf("Wrong columns : %v. Expected : %v", cols, tt.columns)
+			}
+
+			if ds.FeatCount() != tt.feat_counts {
+				t.Errorf("Wrong feature count : %d. Expected : %d", ds.FeatCount(), tt.feat_counts)
+			}
+
+			for s := range ds.Samples() {
+				if s.GetRow() != 0 {
+					continue
+				}
+
+				row := s.GetSampleTestNoErr(-1)
+				if !slices.Equal(row, tt.first_row) {
+					t.Errorf("Wrong features : %v. Expected : %v", row, tt.first_row)
+				}
+
+				if *s.GetTarget() != 1 {
+					t.Error("Target moved after adding columns")
+				}
+			}
+		})
+	}
+	// "a^2" is already taken : "a^3" is not added either
+	ds := dataset.NewDataSet[float32](1)
+	ds.LoadCsvReader(strings.NewReader("a,a^2,y\n2,4,1\n"), ',')
+
+	poly := PolynomialFeatures[float32]{Columns: []string{"a"}, Degree: 3}
+	if err := poly.FitTransform(&ds); err == nil {
+		t.Error("PolynomialFeatures.FitTransform should error when a generated name is taken")
+	}
+
+	if cols := ds.GetColumnNames(); !slices.Equal(cols, []string{"a", "a^2", "y"}) {
+		t.Errorf("Dataset should be left untouched : %v", cols)
+	}
+}
+
+func TestPolynomialFeatures_Generate(t *testing.T) {
+	ds := dataset.NewDataSet[float32](2)
+	ds.LoadCsvReader(strings.NewReader("a,b,y\n2,3,1\n1,,0\n"), ',')
+
+	poly := PolynomialFeatures[float32]{Columns: []string{"a", "b"}, Degree: 2, IncludeBias: true}
+	if err := poly.Fit(&ds); err != nil {
+		t.Errorf("PolynomialFeatures.Fit should not error : %v", err)
+		t.FailNow()
+	}
+
+	if names := poly.FeatureNames(); !slices.Equal(names, []string{"1", "a^2", "a*b", "b^2"}) {
+		t.Errorf("Wrong feature names : %v", names)
+	}
+
+	columns, err := poly.Generate(&ds)
+	if err != nil {
+		t.Errorf("PolynomialFeatures.Generate should not error : %v", err)
+		t.FailNow()
+	}
+
+	expected := [][]*float32{{ptr(1), ptr(1)}, {ptr(4), ptr(1)}, {ptr(6), nil}, {ptr(9), nil}}
+	for k, column := range columns {
+		for i, cell := range column {
+			c, ok := cell.(*dataset.RealDataCell[float32])
+			if (expected[k][i] == nil) != (cell == nil) || ok && c.Value != *expected[k][i] {
+				t.Errorf("Wrong cell (%d, %d) : %v", i, k, cell)
+			}
+		}
+	}
+
+	if cols := ds.GetColumnNames(); !slices.Equal(cols, []string{"a", "b", "y"}) {
+		t.Errorf("Generate should not modify the dataset : %v", cols)
+	}
+}
+
+func ptr(v float32) *float32 {
+	return &v
+}
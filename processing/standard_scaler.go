@@ -2,6 +2,7 @@ package processing
 
 import (
 	"iter"
+	"math"
 
 	"github.com/bleak-and-bare/machine_learning/internal/dataset"
 	"github.com/bleak-and-bare/machine_learning/internal/iterable"
@@ -20,6 +21,13 @@ func (s *StandardScaler[T]) Fit(it iter.Seq[T]) {
 	s.stdev = maths.Stdev(it)
 }
 
+// Weighted mean and standard deviation of (value, weight) pairs
+func (s *StandardScaler[T]) FitWeighted(it iter.Seq2[T, T]) {
+	v, mean, _ := maths.WeightedWelfordVar(it)
+	s.mean = mean
+	s.stdev = T(math.Sqrt(float64(v)))
+}
+
 func (s *StandardScaler[T]) InverseTransform(pred []T) []T {
 	real := make([]T, len(pred))
 	for i := range pred {
@@ -46,6 +54,7 @@ func (s *StandardScaler[T]) FitTransform(it iter.Seq[*T]) {
 	s.Transform(it)
 }
 
+// Statistics are weighted by the sample weights of ds if any
 func (s *StandardScaler[T]) FitTransformDataSet(ds *dataset.DataSet[T], col string) {
 	ptr_it := iterable.Map(ds.Column(col), func(v dataset.DataCell) *T {
 		if v == nil || !v.IsReal() {
@@ -56,7 +65,20 @@ func (s *StandardScaler[T]) FitTransformDataSet(ds *dataset.DataSet[T], col stri
 		return &c.Value
 	})
 
-	s.FitTransform(ptr_it)
+	if !ds.Weighted() {
+		s.FitTransform(ptr_it)
+		return
+	}
+
+	j := ds.ColumnIndex(col)
+	s.FitWeighted(func(yield func(T, T) bool) {
+		for sample := range ds.Samples() {
+			if c, ok := sample.At(j).(*dataset.RealDataCell[T]); ok && !yield(c.Value, sample.GetWeight()) {
+				return
+			}
+		}
+	})
+	s.Transform(ptr_it)
 }
 
 func (s *StandardScaler[T]) TransformDataSet(ds *dataset.DataSet[T], col string) {
@@ -1,8 +1,12 @@
 package processing
 
 import (
+	"math"
 	"slices"
+	"strings"
 	"testing"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
 )
 
 func TestStandardScaler_Fit(t *testing.T) {
@@ -44,3 +48,18 @@ func TestStandardScaler_Fit(t *testing.T) {
 		})
 	}
 }
+
+func TestStandardScaler_FitTransformDataSet_Weighted(t *testing.T) {
+	ds := dataset.NewDataSet[float64](0, dataset.WithTarget("y"), dataset.WithWeights("w"))
+	if err := ds.LoadCsvReader(strings.NewReader("x,w,y\n1,1,0\n2,3,0\n"), ','); err != nil {
+		t.Fatalf("Failed to load CSV : %v", err)
+	}
+
+	var s StandardScaler[float64]
+	s.FitTransformDataSet(&ds, "x")
+
+	// same as fitting 1, 2, 2, 2
+	if math.Abs(s.mean-1.75) > 1e-12 || math.Abs(s.stdev-math.Sqrt(0.1875)) > 1e-12 {
+		t.Errorf("Wrong weighted statistics : mean %v, stdev %v", s.mean, s.stdev)
+	}
+}
@@ -0,0 +1,158 @@
+package text
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"github.com/bleak-and-bare/machine_learning/internal/maths/sparse"
+	"golang.org/x/exp/constraints"
+)
+
+/*
+Bag of words : one column per token of the vocabulary learnt by Fit, in alphabetical order, holding its count in the document.
+Tokens out of the vocabulary are ignored
+*/
+type CountVectorizer[T constraints.Float] struct {
+	Tokenizer   Tokenizer
+	MinDf       int     // tokens found in fewer documents are ignored
+	MaxDf       float64 // tokens found in a larger proportion of the documents are ignored, no limit when zero
+	MaxFeatures int     // only keep the most frequent tokens, no limit when zero
+	Binary      bool    // 1 for every present token instead of its count
+	vocabulary  map[string]int
+	tokens      []string
+}
+
+func NewCountVectorizer[T constraints.Float]() CountVectorizer[T] {
+	return CountVectorizer[T]{
+		Tokenizer: NewTokenizer(),
+		MinDf:     1,
+	}
+}
+
+func (v *CountVectorizer[T]) Fit(docs iter.Seq[string]) error {
+	if err := v.Tokenizer.check(); err != nil {
+		return fmt.Errorf("CountVectorizer.Fit : %w", err)
+	}
+
+	if v.MaxDf < 0 || v.MaxDf > 1 {
+		return errors.New("CountVectorizer.Fit : MaxDf must be a proportion")
+	}
+
+	df := make(map[string]int)
+	counts := make(map[string]int)
+	n := 0
+
+	for doc := range docs {
+		seen := make(map[string]bool)
+		for _, token := range v.Tokenizer.Tokens(doc) {
+			counts[token]++
+			if !seen[token] {
+				seen[token] = true
+				df[token]++
+			}
+		}
+		n++
+	}
+
+	tokens := slices.Collect(maps.Keys(df))
+	tokens = slices.DeleteFunc(tokens, func(token string) bool {
+		return df[token] < v.MinDf || (v.MaxDf > 0 && float64(df[token]) > v.MaxDf*float64(n))
+	})
+
+	if v.MaxFeatures > 0 && len(tokens) > v.MaxFeatures {
+		slices.SortFunc(tokens, func(a, b string) int {
+			if counts[a] != counts[b] {
+				return counts[b] - counts[a]
+			}
+			return strings.Compare(a, b)
+		})
+		tokens = tokens[:v.MaxFeatures]
+	}
+
+	slices.Sort(tokens)
+	v.tokens = tokens
+	v.vocabulary = make(map[string]int, len(tokens))
+	for j, token := range tokens {
+		v.vocabulary[token] = j
+	}
+
+	return nil
+}
+
+// Token of every column
+func (v *CountVectorizer[T]) Vocabulary() []string {
+	return v.tokens
+}
+
+func (v *CountVectorizer[T]) Transform(docs iter.Seq[string]) (sparse.CSR[T], error) {
+	if v.vocabulary == nil {
+		return sparse.CSR[T]{}, errors.New("CountVectorizer.Transform : call Fit first")
+	}
+
+	m := sparse.NewCSR[T](len(v.tokens))
+	for doc := range docs {
+		counts := make(map[int]T)
+		for _, token := range v.Tokenizer.Tokens(doc) {
+			if j, found := v.vocabulary[token]; found {
+				if v.Binary {
+					counts[j] = 1
+				} else {
+					counts[j]++
+				}
+			}
+		}
+
+		indices := slices.Sorted(maps.Keys(counts))
+		values := make([]T, len(indices))
+		for k, j := range indices {
+			values[k] = counts[j]
+		}
+
+		if err := m.AppendRow(indices, values); err != nil {
+			return sparse.CSR[T]{}, err
+		}
+	}
+
+	return m, nil
+}
+
+func (v *CountVectorizer[T]) FitTransform(docs iter.Seq[string]) (sparse.CSR[T], error) {
+	if err := v.Fit(docs); err != nil {
+		return sparse.CSR[T]{}, err
+	}
+	return v.Transform(docs)
+}
+
+/*
+Append the counts of the text column to the sparse features of ds, see dataset.DataSet.AddSparseFeatures.
+The text column itself is kept, drop it before fitting a model
+*/
+func (v *CountVectorizer[T]) TransformDataSet(ds *dataset.DataSet[T], column string) error {
+	docs, err := documents(ds, column)
+	if err != nil {
+		return fmt.Errorf("CountVectorizer.TransformDataSet : %w", err)
+	}
+
+	m, err := v.Transform(docs)
+	if err != nil {
+		return err
+	}
+	return ds.AddSparseFeatures(&m)
+}
+
+func (v *CountVectorizer[T]) FitTransformDataSet(ds *dataset.DataSet[T], column string) error {
+	docs, err := documents(ds, column)
+	if err != nil {
+		return fmt.Errorf("CountVectorizer.FitTransformDataSet : %w", err)
+	}
+
+	if err := v.Fit(docs); err != nil {
+		return err
+	}
+	return v.TransformDataSet(ds, column)
+}
@@ -0,0 +1,30 @@
+package text
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"golang.org/x/exp/constraints"
+)
+
+// Text of every row of the column. Empty and non string cells give empty documents
+func documents[T constraints.Float](ds *dataset.DataSet[T], column string) (iter.Seq[string], error) {
+	j := ds.ColumnIndex(column)
+	if j == -1 {
+		return nil, fmt.Errorf("column %q not found", column)
+	}
+
+	return func(yield func(string) bool) {
+		for s := range ds.Samples() {
+			doc := ""
+			if c, ok := s.At(j).(*dataset.StrDataCell); ok {
+				doc = c.Value
+			}
+
+			if !yield(doc) {
+				return
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,98 @@
+package text
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"iter"
+	"maps"
+	"slices"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"github.com/bleak-and-bare/machine_learning/internal/maths/sparse"
+	"golang.org/x/exp/constraints"
+)
+
+/*
+Bag of words without vocabulary : the column of a token is its hash modulo NFeatures.
+Nothing is learnt, so documents can be streamed and unseen tokens still count, at the cost of collisions
+and of the columns not being invertible into tokens
+*/
+type HashingVectorizer[T constraints.Float] struct {
+	Tokenizer     Tokenizer
+	NFeatures     int  // column count
+	AlternateSign bool // the hash also gives a sign to every token, so that collisions tend to cancel out
+	Binary        bool // 1 for every present token instead of its count
+}
+
+func NewHashingVectorizer[T constraints.Float]() HashingVectorizer[T] {
+	return HashingVectorizer[T]{
+		Tokenizer:     NewTokenizer(),
+		NFeatures:     1 << 20,
+		AlternateSign: true,
+	}
+}
+
+func (v *HashingVectorizer[T]) column(token string) (int, T) {
+	h := fnv.New64a()
+	h.Write([]byte(token))
+	sum := h.Sum64()
+
+	sign := T(1)
+	if v.AlternateSign && sum>>63 == 1 {
+		sign = -1
+	}
+	return int(sum % uint64(v.NFeatures)), sign
+}
+
+func (v *HashingVectorizer[T]) Transform(docs iter.Seq[string]) (sparse.CSR[T], error) {
+	if v.NFeatures < 1 {
+		return sparse.CSR[T]{}, errors.New("HashingVectorizer.Transform : NFeatures must be positive")
+	}
+
+	if err := v.Tokenizer.check(); err != nil {
+		return sparse.CSR[T]{}, fmt.Errorf("HashingVectorizer.Transform : %w", err)
+	}
+
+	m := sparse.NewCSR[T](v.NFeatures)
+	for doc := range docs {
+		counts := make(map[int]T)
+		for _, token := range v.Tokenizer.Tokens(doc) {
+			j, sign := v.column(token)
+			if v.Binary {
+				counts[j] = sign
+			} else {
+				counts[j] += sign
+			}
+		}
+
+		indices := slices.Sorted(maps.Keys(counts))
+		values := make([]T, len(indices))
+		for k, j := range indices {
+			values[k] = counts[j]
+		}
+
+		if err := m.AppendRow(indices, values); err != nil {
+			return sparse.CSR[T]{}, err
+		}
+	}
+
+	return m, nil
+}
+
+/*
+Append the hashed counts of the text column to the sparse features of ds, see dataset.DataSet.AddSparseFeatures.
+The text column itself is kept, drop it before fitting a model
+*/
+func (v *HashingVectorizer[T]) TransformDataSet(ds *dataset.DataSet[T], column string) error {
+	docs, err := documents(ds, column)
+	if err != nil {
+		return fmt.Errorf("HashingVectorizer.TransformDataSet : %w", err)
+	}
+
+	m, err := v.Transform(docs)
+	if err != nil {
+		return err
+	}
+	return ds.AddSparseFeatures(&m)
+}
@@ -0,0 +1,197 @@
+package text
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"github.com/bleak-and-bare/machine_learning/regression/linear"
+)
+
+func TestTokenizer_Tokens(t *testing.T) {
+	tok := NewTokenizer()
+	tok.StopWords = EnglishStopWords()
+	tok.NGramMax = 2
+
+	tokens := tok.Tokens("The printer is OUT of paper, a paper jam!")
+	expected := []string{"printer", "out", "paper", "paper", "jam", "printer out", "out paper", "paper paper", "paper jam"}
+	if !slices.Equal(tokens, expected) {
+		t.Errorf("Wrong tokens : %q", tokens)
+	}
+
+	tok.NGramMin = 3
+	if err := tok.check(); err == nil {
+		t.Error("Should reject an empty n-gram range")
+	}
+}
+
+func TestCountVectorizer_Tfidf(t *testing.T) {
+	docs := []string{"red apple", "green apple apple", "red car"}
+
+	v := NewCountVectorizer[float64]()
+	counts, err := v.FitTransform(slices.Values(docs))
+	if err != nil {
+		t.Errorf("CountVectorizer.FitTransform should not error : %v", err)
+		t.FailNow()
+	}
+
+	if !slices.Equal(v.Vocabulary(), []string{"apple", "car", "green", "red"}) {
+		t.Errorf("Wrong vocabulary : %v", v.Vocabulary())
+	}
+
+	if counts.At(1, 0) != 2 || counts.At(1, 2) != 1 || counts.At(2, 0) != 0 || counts.NNZ() != 6 {
+		t.Errorf("Wrong counts : %+v", counts)
+	}
+
+	unseen, _ := v.Transform(slices.Values([]string{"blue car"}))
+	if unseen.NNZ() != 1 || unseen.At(0, 1) != 1 {
+		t.Errorf("Unknown tokens should be ignored : %+v", unseen)
+	}
+
+	v.MinDf = 2
+	v.Fit(slices.Values(docs))
+	if !slices.Equal(v.Vocabulary(), []string{"apple", "red"}) {
+		t.Errorf("Wrong vocabulary with MinDf : %v", v.Vocabulary())
+	}
+
+	tfidf := NewTfidfTransformer[float64]()
+	w, err := tfidf.FitTransform(&counts)
+	if err != nil {
+		t.Errorf("TfidfTransformer.FitTransform should not error : %v", err)
+		t.FailNow()
+	}
+
+	// apple is in 2 of 3 documents, car in 1
+	if idf := tfidf.Idf(); math.Abs(idf[0]-(math.Log(4.0/3)+1)) > 1e-9 || math.Abs(idf[1]-(math.Log(2)+1)) > 1e-9 {
+		t.Errorf("Wrong idf : %v", idf)
+	}
+
+	for i := range w.Rows() {
+		_, values := w.Row(i)
+		var norm float64
+		for _, v := range values {
+			norm += v * v
+		}
+
+		if math.Abs(norm-1) > 1e-9 {
+			t.Errorf("Row %d should have a unit norm : %v", i, norm)
+		}
+	}
+}
+
+func TestHashingVectorizer_Transform(t *testing.T) {
+	v := NewHashingVectorizer[float64]()
+	v.NFeatures = 16
+
+	m, err := v.Transform(slices.Values([]string{"spam spam eggs", "", "eggs"}))
+	if err != nil {
+		t.Errorf("HashingVectorizer.Transform should not error : %v", err)
+		t.FailNow()
+	}
+
+	if m.Rows() != 3 || m.Cols() != 16 {
+		t.Errorf("Wrong shape : %dx%d", m.Rows(), m.Cols())
+	}
+
+	j, sign := v.column("eggs")
+	if m.At(2, j) != sign || m.At(0, j) != sign {
+		t.Errorf("Same token should hash to the same column : %v", m.At(2, j))
+	}
+
+	spam, sign := v.column("spam")
+	if spam != j && m.At(0, spam) != 2*sign {
+		t.Errorf("Wrong count of repeated token : %v", m.At(0, spam))
+	}
+
+	v.NFeatures = 0
+	if _, err := v.Transform(slices.Values([]string{"eggs"})); err == nil {
+		t.Error("Should reject zero features")
+	}
+}
+
+func TestCountVectorizer_LinearRegression(t *testing.T) {
+	ds := dataset.NewDataSet[float64](0, dataset.WithTarget("score"))
+	str := strings.NewReader(`review,score
+great product,1
+great great service,2
+bad product,-1
+bad service,-1
+,0
+great,1`)
+
+	if err := ds.LoadCsvReader(str, ','); err != nil {
+		t.Fatalf("Failed to load CSV : %v", err)
+	}
+
+	v := NewCountVectorizer[float64]()
+	if err := v.FitTransformDataSet(&ds, "review"); err != nil {
+		t.Errorf("CountVectorizer.FitTransformDataSet should not error : %v", err)
+		t.FailNow()
+	}
+	ds.DropColumn("review")
+
+	if ds.FeatCount() != 4 || ds.SparseFeatCount() != 4 {
+		t.Errorf("Wrong feature count : %d", ds.FeatCount())
+	}
+
+	m := linear.NewLinearReg[float64]()
+	m.Alpha = 0.1
+	if err := m.Fit(&ds); err != nil {
+		t.Errorf("LinearRegression.Fit should not error : %v", err)
+		t.FailNow()
+	}
+
+	if r := m.PredictOn(&ds); r.Score < 0.95 || r.SkippedRows != 0 {
+		t.Errorf("Bag of words should explain the scores : %+v", r)
+	}
+}
+
+// Only the count columns are reweighted, other sparse features are left as is
+func TestTfidfTransformer_TransformDataSet(t *testing.T) {
+	ds := dataset.NewDataSet[float64](0, dataset.WithTarget("score"))
+	str := strings.NewReader(`n,review,score
+3,red apple,1
+0,green apple apple,2
+5,red car,0`)
+
+	if err := ds.LoadCsvReader(str, ','); err != nil {
+		t.Fatalf("Failed to load CSV : %v", err)
+	}
+
+	if err := ds.Sparsify("n"); err != nil {
+		t.Fatalf("Failed to sparsify : %v", err)
+	}
+
+	first := ds.SparseFeatCount()
+	v := NewCountVectorizer[float64]()
+	if err := v.FitTransformDataSet(&ds, "review"); err != nil {
+		t.Fatalf("CountVectorizer.FitTransformDataSet should not error : %v", err)
+	}
+
+	tfidf := NewTfidfTransformer[float64]()
+	if err := tfidf.FitTransformDataSet(&ds, first); err != nil {
+		t.Errorf("TfidfTransformer.FitTransformDataSet should not error : %v", err)
+		t.FailNow()
+	}
+
+	m := ds.SparseFeatures()
+	for i, n := range []float64{3, 0, 5} {
+		if m.At(i, 0) != n {
+			t.Errorf("Sparse feature outside of the counts changed at row %d : %v", i, m.At(i, 0))
+		}
+
+		var norm float64
+		for j := first; j < m.Cols(); j++ {
+			norm += m.At(i, j) * m.At(i, j)
+		}
+		if math.Abs(norm-1) > 1e-9 {
+			t.Errorf("Counts of row %d should have a unit norm : %v", i, norm)
+		}
+	}
+
+	if err := tfidf.TransformDataSet(&ds, first+1); err == nil {
+		t.Error("Should not reweight columns past the sparse features")
+	}
+}
@@ -0,0 +1,162 @@
+package text
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"github.com/bleak-and-bare/machine_learning/internal/maths/sparse"
+	"golang.org/x/exp/constraints"
+)
+
+/*
+Reweights token counts by their inverse document frequency, idf = ln(n / df) + 1, so that tokens found everywhere weigh less.
+Transform multiplies every count by the idf of its column
+*/
+type TfidfTransformer[T constraints.Float] struct {
+	SmoothIdf bool // idf = ln((1 + n) / (1 + df)) + 1, as if one more document held every token
+	Sublinear bool // 1 + ln(tf) instead of tf
+	Normalize bool // scale rows to a unit euclidean norm
+	idf       []T
+}
+
+func NewTfidfTransformer[T constraints.Float]() TfidfTransformer[T] {
+	return TfidfTransformer[T]{
+		SmoothIdf: true,
+		Normalize: true,
+	}
+}
+
+func (t *TfidfTransformer[T]) Fit(counts *sparse.CSR[T]) {
+	df := make([]int, counts.Cols())
+	for _, j := range counts.Indices {
+		df[j]++
+	}
+
+	n := float64(counts.Rows())
+	t.idf = make([]T, len(df))
+	for j := range df {
+		if t.SmoothIdf {
+			t.idf[j] = T(math.Log((1+n)/(1+float64(df[j]))) + 1)
+		} else {
+			// tokens missing from every document are seen as found once
+			t.idf[j] = T(math.Log(n/float64(max(df[j], 1))) + 1)
+		}
+	}
+}
+
+// Inverse document frequency of every column
+func (t *TfidfTransformer[T]) Idf() []T {
+	return t.idf
+}
+
+func (t *TfidfTransformer[T]) Transform(counts *sparse.CSR[T]) (sparse.CSR[T], error) {
+	if t.idf == nil {
+		return sparse.CSR[T]{}, errors.New("TfidfTransformer.Transform : call Fit first")
+	}
+
+	if counts.Cols() != len(t.idf) {
+		return sparse.CSR[T]{}, fmt.Errorf("TfidfTransformer.Transform : %d columns provided, %d expected", counts.Cols(), len(t.idf))
+	}
+
+	m := counts.Clone()
+	for i := range m.Rows() {
+		indices, values := m.Row(i)
+
+		var norm T
+		for k, j := range indices {
+			tf := values[k]
+			if t.Sublinear && tf > 0 {
+				tf = 1 + T(math.Log(float64(tf)))
+			}
+			values[k] = tf * t.idf[j]
+			norm += values[k] * values[k]
+		}
+
+		if t.Normalize && norm > 0 {
+			norm = T(math.Sqrt(float64(norm)))
+			for k := range values {
+				values[k] /= norm
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (t *TfidfTransformer[T]) FitTransform(counts *sparse.CSR[T]) (sparse.CSR[T], error) {
+	t.Fit(counts)
+	return t.Transform(counts)
+}
+
+// Columns first to first+count of m, numbered from 0
+func column_range[T constraints.Float](m *sparse.CSR[T], first, count int) sparse.CSR[T] {
+	r := sparse.NewCSR[T](count)
+	for i := range m.Rows() {
+		var indices []int
+		var values []T
+
+		row_indices, row_values := m.Row(i)
+		for k, j := range row_indices {
+			if j >= first && j < first+count {
+				indices = append(indices, j-first)
+				values = append(values, row_values[k])
+			}
+		}
+		r.AppendRow(indices, values)
+	}
+	return r
+}
+
+/*
+Reweights the sparse features of ds from the first-th one, as many as there are idf, leaving the others untouched.
+first is the SparseFeatCount of ds before CountVectorizer.TransformDataSet appended the counts
+*/
+func (t *TfidfTransformer[T]) TransformDataSet(ds *dataset.DataSet[T], first int) error {
+	counts := ds.SparseFeatures()
+	if counts == nil {
+		return errors.New("TfidfTransformer.TransformDataSet : dataset has no sparse feature")
+	}
+
+	if first < 0 || first+len(t.idf) > counts.Cols() {
+		return fmt.Errorf("TfidfTransformer.TransformDataSet : columns %d to %d out of the %d sparse features", first, first+len(t.idf), counts.Cols())
+	}
+
+	sub := column_range(counts, first, len(t.idf))
+	m, err := t.Transform(&sub)
+	if err != nil {
+		return err
+	}
+
+	// entries of the range keep their order, so the reweighted values are copied back one by one
+	for i := range counts.Rows() {
+		indices, values := counts.Row(i)
+		_, weighted := m.Row(i)
+
+		k := 0
+		for p, j := range indices {
+			if j >= first && j < first+len(t.idf) {
+				values[p] = weighted[k]
+				k++
+			}
+		}
+	}
+	return ds.SetSparseFeatures(counts)
+}
+
+// Fits on the count sparse features of ds, from the first-th one to the end, then reweights them
+func (t *TfidfTransformer[T]) FitTransformDataSet(ds *dataset.DataSet[T], first int) error {
+	counts := ds.SparseFeatures()
+	if counts == nil {
+		return errors.New("TfidfTransformer.FitTransformDataSet : dataset has no sparse feature")
+	}
+
+	if first < 0 || first > counts.Cols() {
+		return fmt.Errorf("TfidfTransformer.FitTransformDataSet : column %d out of the %d sparse features", first, counts.Cols())
+	}
+
+	sub := column_range(counts, first, counts.Cols()-first)
+	t.Fit(&sub)
+	return t.TransformDataSet(ds, first)
+}
@@ -0,0 +1,99 @@
+package text
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Words of two or more letters, digits or underscores
+var DefaultTokenPattern = regexp.MustCompile(`\b\w\w+\b`)
+
+/*
+Splits documents into tokens : the matches of Pattern, lowercased if Lowercase is set, without the stop words,
+then joined by a space into every n-gram from NGramMin to NGramMax words
+*/
+type Tokenizer struct {
+	Lowercase bool            // set by NewTokenizer, the zero value keeps the case
+	Pattern   *regexp.Regexp  // DefaultTokenPattern when nil
+	StopWords map[string]bool // removed before building the n-grams, see NewStopWords
+	NGramMin  int             // unigrams when both bounds are zero
+	NGramMax  int
+}
+
+func NewTokenizer() Tokenizer {
+	return Tokenizer{
+		Lowercase: true,
+		NGramMin:  1,
+		NGramMax:  1,
+	}
+}
+
+func NewStopWords(words ...string) map[string]bool {
+	stop_words := make(map[string]bool, len(words))
+	for _, w := range words {
+		stop_words[w] = true
+	}
+	return stop_words
+}
+
+// A short list of common english words
+func EnglishStopWords() map[string]bool {
+	return NewStopWords(
+		"a", "about", "after", "all", "also", "am", "an", "and", "any", "are", "as", "at",
+		"be", "been", "before", "being", "but", "by", "can", "could", "did", "do", "does", "for", "from",
+		"had", "has", "have", "he", "her", "his", "how", "i", "if", "in", "into", "is", "it", "its",
+		"me", "my", "no", "not", "of", "on", "or", "our", "she", "so", "than", "that", "the", "their",
+		"them", "then", "there", "these", "they", "this", "to", "was", "we", "were", "what", "when",
+		"which", "who", "will", "with", "would", "you", "your",
+	)
+}
+
+func (t *Tokenizer) ngrams() (int, int) {
+	if t.NGramMin == 0 && t.NGramMax == 0 {
+		return 1, 1
+	}
+	return t.NGramMin, t.NGramMax
+}
+
+func (t *Tokenizer) check() error {
+	if lo, hi := t.ngrams(); lo < 1 || hi < lo {
+		return errors.New("Tokenizer : invalid n-gram range")
+	}
+	return nil
+}
+
+func (t *Tokenizer) Tokens(doc string) []string {
+	if t.Lowercase {
+		doc = strings.ToLower(doc)
+	}
+
+	pattern := t.Pattern
+	if pattern == nil {
+		pattern = DefaultTokenPattern
+	}
+
+	words := pattern.FindAllString(doc, -1)
+	if t.StopWords != nil {
+		kept := words[:0]
+		for _, w := range words {
+			if !t.StopWords[w] {
+				kept = append(kept, w)
+			}
+		}
+		words = kept
+	}
+
+	lo, hi := t.ngrams()
+	if lo == 1 && hi == 1 {
+		return words
+	}
+
+	var tokens []string
+	for n := lo; n <= hi; n++ {
+		for k := 0; k+n <= len(words); k++ {
+			tokens = append(tokens, strings.Join(words[k:k+n], " "))
+		}
+	}
+	return tokens
+}
@@ -6,19 +6,21 @@ import (
 	"slices"
 
 	"github.com/bleak-and-bare/machine_learning/internal/dataset"
-	"github.com/bleak-and-bare/machine_learning/internal/iterable"
 	"github.com/bleak-and-bare/machine_learning/internal/maths"
 	"github.com/bleak-and-bare/machine_learning/internal/maths/metrics"
 	"github.com/bleak-and-bare/machine_learning/internal/maths/optimization"
-	"github.com/bleak-and-bare/machine_learning/internal/maths/vector"
 	"github.com/bleak-and-bare/machine_learning/regression"
 	"golang.org/x/exp/constraints"
 )
 
 type LinearRegression[T constraints.Float] struct {
-	theta     []T     // parameter list
-	Alpha     float32 // learning rate
-	Threshold maths.Threshold
+	theta       []T                        // parameter list
+	Alpha       float32                    // learning rate, 1e-4 when left to 0
+	WarmStart   bool                       // continue from the current parameters on the next Fit
+	Threshold   maths.Threshold            // maths.DefThreshold() when left to its zero value
+	Loss        optimization.Loss[T]       // minimized loss, mean squared error when nil
+	NonNegative bool                       // non negative coefficients, i.e non negative least squares with the default loss
+	Constraints optimization.Projection[T] // other constraint sets, takes precedence over NonNegative
 }
 
 func NewLinearReg[T constraints.Float]() LinearRegression[T] {
@@ -71,20 +73,39 @@ func linear_reg_cost_partial_diff[T constraints.Float](j int, theta []T, ds *dat
 	return optimization.PartialDiffMSE(j, theta, ds, &h)
 }
 
+// Goes through DataSample.DotProduct so that sparse features only cost their non zero entries
 func linear_reg_cost[T constraints.Float](theta []T, ds *dataset.DataSet[T]) T {
-	return optimization.MSE(theta, ds, func(theta []T, x []T) T {
-		return theta[0] + vector.DotProduct(
-			iterable.Skip(slices.Values(theta), 1),
-			slices.Values(x),
-		)
-	}, 0.0)
+	return optimization.Cost(theta, ds, &linear_reg_hypo[T]{}, optimization.SquaredErr[T]())
 }
 
 func (m *LinearRegression[T]) Fit(ds *dataset.DataSet[T]) error {
-	sgd := optimization.NewSGD[T](m.Threshold)
-	sgd.Alpha = m.Alpha
-	sgd.CostPartialDiff = linear_reg_cost_partial_diff
-	sgd.Cost = linear_reg_cost
+	// zero Threshold and Alpha fall back to NewLinearReg defaults so LinearRegression{Loss: ...} is usable as is
+	th := m.Threshold
+	if th == (maths.Threshold{}) {
+		th = maths.DefThreshold()
+	}
+
+	sgd := optimization.NewSGD[T](th)
+	if m.Alpha != 0 {
+		sgd.Alpha = m.Alpha
+	}
+	if m.Loss != nil {
+		sgd.UseLoss(&linear_reg_hypo[T]{}, m.Loss)
+	} else {
+		sgd.CostPartialDiff = linear_reg_cost_partial_diff
+		sgd.Cost = linear_reg_cost
+	}
+	// sparse features are only fast through the one pass gradient, dense ones keep the parallel partial derivatives
+	if ds.SparseFeatCount() > 0 {
+		sgd.CostGradient = func(theta []T, ds *dataset.DataSet[T]) (map[int]T, error) {
+			return optimization.LinearGradient(theta, ds, m.Loss)
+		}
+	}
+	sgd.Projection = m.constraints()
+	if m.WarmStart {
+		sgd.WarmStart = true
+		sgd.SetParams(m.theta)
+	}
 
 	if err := sgd.Fit(ds); err != nil {
 		return err
@@ -95,6 +116,18 @@ func (m *LinearRegression[T]) Fit(ds *dataset.DataSet[T]) error {
 	return nil
 }
 
+func (m *LinearRegression[T]) constraints() optimization.Projection[T] {
+	if m.Constraints != nil {
+		return m.Constraints
+	}
+
+	if m.NonNegative {
+		return optimization.NonNegative[T]()
+	}
+
+	return nil
+}
+
 func (m *LinearRegression[T]) PredictOn(ds *dataset.DataSet[T]) regression.RegressionReport[T] {
 	r := regression.RegressionReport[T]{
 		DataSet: ds,
@@ -102,14 +135,9 @@ func (m *LinearRegression[T]) PredictOn(ds *dataset.DataSet[T]) regression.Regre
 
 	targets := make([]T, 0, ds.Size())
 	predictions := make([]T, 0, ds.Size())
+	weights := make([]T, 0, ds.Size())
 	for ds := range ds.Samples() {
-		sample, err := ds.GetSampleTest()
-		if err != nil {
-			r.SkippedRows++
-			continue
-		}
-
-		pred, err := m.Predict(sample)
+		pred, err := m.PredictSample(&ds)
 		if err != nil {
 			r.SkippedRows++
 			continue
@@ -118,12 +146,24 @@ func (m *LinearRegression[T]) PredictOn(ds *dataset.DataSet[T]) regression.Regre
 		if y := ds.GetTarget(); y != nil {
 			targets = append(targets, *y)
 			predictions = append(predictions, pred)
+			weights = append(weights, ds.GetWeight())
 		} else {
 			r.SkippedRows++
 		}
 	}
 
+	if c := m.constraints(); c != nil {
+		r.ActiveConstraints = c.Active(m.theta)
+	}
+
 	r.Predictions = predictions
+	if ds.Weighted() {
+		r.Score = metrics.WeightedR2Score(slices.Values(targets), slices.Values(predictions), slices.Values(weights))
+		r.RootMeanSquareErr = metrics.WeightedRMSE(slices.Values(targets), slices.Values(predictions), slices.Values(weights))
+		r.MeanAbsoluteErr = metrics.WeightedMAE(slices.Values(targets), slices.Values(predictions), slices.Values(weights))
+		return r
+	}
+
 	r.Score = metrics.R2Score(slices.Values(targets), slices.Values(predictions))
 	r.RootMeanSquareErr = metrics.RMSE(slices.Values(targets), slices.Values(predictions))
 	r.MeanAbsoluteErr = metrics.MAE(slices.Values(targets), slices.Values(predictions))
@@ -131,6 +171,23 @@ func (m *LinearRegression[T]) PredictOn(ds *dataset.DataSet[T]) regression.Regre
 	return r
 }
 
+// Same as Predict without building the feature vector, so that sparse features only cost their non zero entries
+func (m *LinearRegression[T]) PredictSample(s *dataset.DataSample[T]) (T, error) {
+	if len(m.theta) == 0 {
+		return 0.0, errors.New("Using non-fit model")
+	}
+
+	if s.FeatCount() != len(m.theta)-1 {
+		return 0.0, errors.New("Invalid sample provided")
+	}
+
+	d, err := s.DotProduct(m.theta[1:])
+	if err != nil {
+		return 0.0, err
+	}
+	return m.theta[0] + d, nil
+}
+
 func (m *LinearRegression[T]) Predict(x []T) (T, error) {
 	if len(m.theta) == 0 {
 		return 0.0, errors.New("Using non-fit model")
@@ -2,10 +2,13 @@ package linear
 
 import (
 	"math"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"github.com/bleak-and-bare/machine_learning/internal/maths/optimization"
+	"github.com/bleak-and-bare/machine_learning/internal/maths/optimization/optimtest"
 	"github.com/bleak-and-bare/machine_learning/processing"
 )
 
@@ -65,3 +68,76 @@ func TestLinearRegression_PredictOn(t *testing.T) {
 		t.Error("Bad score")
 	}
 }
+
+func TestLinearRegression_HuberLoss(t *testing.T) {
+	ds := dataset.NewDataSet[float32](1)
+	str := strings.NewReader(`x,y
+0,0
+1,1
+2,2
+3,3
+4,4
+5,5
+6,6
+7,7
+8,8
+9,90`)
+
+	ds.LoadCsvReader(str, ',')
+	huber, _ := optimization.Huber[float32](1.35)
+	m := LinearRegression[float32]{Loss: huber, Alpha: 1e-2}
+
+	if err := m.Fit(&ds); err != nil {
+		t.Errorf("LinearRegression.Fit should not error : %v", err)
+		t.FailNow()
+	}
+
+	// the outlier would pull the squared error slope far above 1
+	pred, _ := m.Predict([]float32{4})
+	if math.Abs(float64(pred-4)) > 1 {
+		t.Errorf("Huber fit is not robust to the outlier : Predict(4) = %.3f", pred)
+	}
+}
+
+func TestLinearRegression_NonNegative(t *testing.T) {
+	ds := dataset.NewDataSet[float32](2)
+	str := strings.NewReader(`a,b,y
+0,0,1
+1,0,3
+0,1,0
+1,1,2
+2,1,4
+1,2,1`)
+
+	ds.LoadCsvReader(str, ',')
+	m := NewLinearReg[float32]()
+	m.Alpha = 1e-2
+	m.NonNegative = true
+
+	if err := m.Fit(&ds); err != nil {
+		t.Errorf("LinearRegression.Fit should not error : %v", err)
+		t.FailNow()
+	}
+
+	// unconstrained solution is y = 1 + 2a - b
+	if m.theta[2] < 0 || math.Abs(float64(m.theta[2])) > 1e-6 {
+		t.Errorf("Coefficient of b should be clamped to 0, got %.3f", m.theta[2])
+	}
+
+	r := m.PredictOn(&ds)
+	if !slices.Contains(r.ActiveConstraints, "theta[2] >= 0") {
+		t.Errorf("Report should list the non negativity of b as active : %v", r.ActiveConstraints)
+	}
+}
+
+func TestLinearRegression_Gradient(t *testing.T) {
+	ds := dataset.NewDataSet[float32](2)
+	str := strings.NewReader(`a,b,y
+0.5,1,1
+1,-2,3
+2,0.3,0`)
+
+	ds.LoadCsvReader(str, ',')
+	optimtest.AssertGradient(t, &linear_reg_hypo[float32]{}, &ds, 1e-2)
+	optimtest.AssertCostGradient(t, linear_reg_cost, linear_reg_cost_partial_diff, &ds, 1e-2)
+}
@@ -0,0 +1,212 @@
+package linear
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+	"github.com/bleak-and-bare/machine_learning/internal/maths/matrix"
+	"github.com/bleak-and-bare/machine_learning/internal/maths/metrics"
+	"github.com/bleak-and-bare/machine_learning/regression"
+	"golang.org/x/exp/constraints"
+)
+
+// Predicts every target of a dataset, see DataSet.SetTargets
+type MultiOutputRegressor[T constraints.Float] struct {
+	models []LinearRegression[T] // one per target
+	Base   LinearRegression[T]   // settings of the per target models
+
+	// Solve the least squares problem of every target at once through the normal equations, weighted by the sample weights
+	// of the dataset if any, instead of fitting one model per target. Rows with an empty feature or target are skipped.
+	// Base is then ignored
+	Joint bool
+}
+
+func NewMultiOutputReg[T constraints.Float]() MultiOutputRegressor[T] {
+	return MultiOutputRegressor[T]{
+		Base: NewLinearReg[T](),
+	}
+}
+
+// Model of the k-th target
+func (m *MultiOutputRegressor[T]) Model(k int) *LinearRegression[T] {
+	return &m.models[k]
+}
+
+func (m *MultiOutputRegressor[T]) Fit(ds *dataset.DataSet[T]) error {
+	if m.Joint {
+		return m.fit_joint(ds)
+	}
+
+	n := ds.TargetCount()
+	models := make([]LinearRegression[T], n)
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	for k := range n {
+		models[k] = m.Base
+		if m.Base.WarmStart && k < len(m.models) {
+			models[k].theta = m.models[k].theta
+		}
+
+		wg.Go(func() {
+			view, err := ds.ForTarget(k)
+			if err == nil {
+				err = models[k].Fit(view)
+			}
+			errs[k] = err
+		})
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	m.models = models
+	return nil
+}
+
+func (m *MultiOutputRegressor[T]) fit_joint(ds *dataset.DataSet[T]) error {
+	p := ds.FeatCount() + 1
+	n := ds.TargetCount()
+
+	xtx := matrix.New[float64](p, p)
+	xty := make([][]float64, n)
+	for k := range xty {
+		xty[k] = make([]float64, p)
+	}
+
+	x := make([]float64, p)
+	rows := 0
+	for s := range ds.Samples() {
+		feats, err := s.GetSampleTest()
+		if err != nil {
+			continue
+		}
+
+		ys := s.GetTargets()
+		w := float64(s.GetWeight())
+		if slices.Contains(ys, nil) || w == 0 {
+			continue
+		}
+
+		x[0] = 1
+		for i, f := range feats {
+			x[i+1] = float64(f)
+		}
+
+		for i := range p {
+			for j := range p {
+				xtx.Add(i, j, w*x[i]*x[j])
+			}
+			for k, y := range ys {
+				xty[k][i] += w * x[i] * float64(*y)
+			}
+		}
+		rows++
+	}
+
+	if rows < p {
+		return fmt.Errorf("MultiOutputRegressor.Fit : %d complete rows for %d parameters", rows, p)
+	}
+
+	models := make([]LinearRegression[T], n)
+	for k := range n {
+		theta, err := xtx.Solve(xty[k])
+		if err != nil {
+			return fmt.Errorf("MultiOutputRegressor.Fit : %w", err)
+		}
+
+		models[k].theta = make([]T, p)
+		for j, v := range theta {
+			models[k].theta[j] = T(v)
+		}
+	}
+
+	m.models = models
+	return nil
+}
+
+// One prediction per target
+func (m *MultiOutputRegressor[T]) Predict(x []T) ([]T, error) {
+	if len(m.models) == 0 {
+		return nil, errors.New("Using non-fit model")
+	}
+
+	preds := make([]T, len(m.models))
+	for k := range m.models {
+		pred, err := m.models[k].Predict(x)
+		if err != nil {
+			return nil, err
+		}
+		preds[k] = pred
+	}
+
+	return preds, nil
+}
+
+func (m *MultiOutputRegressor[T]) PredictOn(ds *dataset.DataSet[T]) regression.MultiOutputReport[T] {
+	r := regression.MultiOutputReport[T]{
+		DataSet: ds,
+		Targets: ds.TargetNames(),
+	}
+
+	n := len(r.Targets)
+	r.PerTarget = make([]regression.RegressionReport[T], n)
+	targets := make([][]T, n)
+	weights := make([][]T, n)
+
+	for k := range n {
+		r.PerTarget[k].DataSet, _ = ds.ForTarget(k)
+	}
+
+	for s := range ds.Samples() {
+		sample, err := s.GetSampleTest()
+		if err != nil {
+			r.SkippedRows++
+			continue
+		}
+
+		preds, err := m.Predict(sample)
+		if err != nil || len(preds) != n {
+			r.SkippedRows++
+			continue
+		}
+
+		for k, y := range s.GetTargets() {
+			if y == nil {
+				r.PerTarget[k].SkippedRows++
+				continue
+			}
+
+			targets[k] = append(targets[k], *y)
+			weights[k] = append(weights[k], s.GetWeight())
+			r.PerTarget[k].Predictions = append(r.PerTarget[k].Predictions, preds[k])
+		}
+	}
+
+	for k := range r.PerTarget {
+		report := &r.PerTarget[k]
+		report.SkippedRows += r.SkippedRows
+
+		y, pred, w := slices.Values(targets[k]), slices.Values(report.Predictions), slices.Values(weights[k])
+		if ds.Weighted() {
+			report.Score = metrics.WeightedR2Score(y, pred, w)
+			report.RootMeanSquareErr = metrics.WeightedRMSE(y, pred, w)
+			report.MeanAbsoluteErr = metrics.WeightedMAE(y, pred, w)
+		} else {
+			report.Score = metrics.R2Score(y, pred)
+			report.RootMeanSquareErr = metrics.RMSE(y, pred)
+			report.MeanAbsoluteErr = metrics.MAE(y, pred)
+		}
+
+		r.Score += report.Score / float64(n)
+		r.RootMeanSquareErr += report.RootMeanSquareErr / float64(n)
+		r.MeanAbsoluteErr += report.MeanAbsoluteErr / float64(n)
+	}
+
+	return r
+}
@@ -0,0 +1,109 @@
+package linear
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/bleak-and-bare/machine_learning/internal/dataset"
+)
+
+func multi_output_data_set(t *testing.T) *dataset.DataSet[float32] {
+	ds := dataset.NewDataSet[float32](0, dataset.WithTargets("y1", "y2"))
+	str := strings.NewReader(`x1,y1,x2,y2
+0,1,0,0
+1,3,0,-1
+0,2,1,1
+1,4,1,0
+2,5,0,-2
+2,6,1,-1`)
+
+	if err := ds.LoadCsvReader(str, ','); err != nil {
+		t.Fatalf("Failed to load CSV : %v", err)
+	}
+	return &ds
+}
+
+func TestMultiOutputRegressor_Joint(t *testing.T) {
+	ds := multi_output_data_set(t)
+	if ds.FeatCount() != 2 || ds.TargetCount() != 2 {
+		t.Errorf("Wrong layout : %d features, %d targets", ds.FeatCount(), ds.TargetCount())
+	}
+
+	m := NewMultiOutputReg[float32]()
+	m.Joint = true
+	if err := m.Fit(ds); err != nil {
+		t.Errorf("MultiOutputRegressor.Fit should not error : %v", err)
+		t.FailNow()
+	}
+
+	// y1 = 1 + 2*x1 + x2, y2 = -x1 + x2
+	preds, err := m.Predict([]float32{3, 1})
+	if err != nil || math.Abs(float64(preds[0]-8)) > 1e-3 || math.Abs(float64(preds[1]+2)) > 1e-3 {
+		t.Errorf("Wrong predictions : %v, %v", preds, err)
+	}
+
+	report := m.PredictOn(ds)
+	if len(report.PerTarget) != 2 || report.PerTarget[1].Score < 0.999 || report.Score < 0.999 {
+		t.Errorf("Bad report : %+v", report)
+	}
+}
+
+func TestMultiOutputRegressor_PerTarget(t *testing.T) {
+	ds := multi_output_data_set(t)
+
+	m := NewMultiOutputReg[float32]()
+	m.Base.Alpha = 0.05
+	if err := m.Fit(ds); err != nil {
+		t.Errorf("MultiOutputRegressor.Fit should not error : %v", err)
+		t.FailNow()
+	}
+
+	report := m.PredictOn(ds)
+	if report.SkippedRows > 0 || report.Score < 0.99 {
+		t.Errorf("Bad report : %+v", report)
+	}
+
+	if report.Targets[0] != "y1" || report.Targets[1] != "y2" {
+		t.Errorf("Wrong target names : %v", report.Targets)
+	}
+}
+
+// A row of weight 2 counts as much as the same row twice, in the fit as in the report
+func TestMultiOutputRegressor_Weighted(t *testing.T) {
+	load := func(csv string, opts ...dataset.Option) *dataset.DataSet[float64] {
+		ds := dataset.NewDataSet[float64](0, append(opts, dataset.WithTargets("y1", "y2"))...)
+		if err := ds.LoadCsvReader(strings.NewReader(csv), ','); err != nil {
+			t.Fatalf("Failed to load CSV : %v", err)
+		}
+		return &ds
+	}
+
+	weighted := load("x,w,y1,y2\n0,1,1,0\n1,2,2,5\n2,1,7,1\n3,0.5,4,2\n", dataset.WithWeights("w"))
+	repeated := load("x,y1,y2\n0,1,0\n0,1,0\n1,2,5\n1,2,5\n1,2,5\n1,2,5\n2,7,1\n2,7,1\n3,4,2\n")
+
+	var reports [2]float64
+	var preds [2][]float64
+	for i, ds := range []*dataset.DataSet[float64]{weighted, repeated} {
+		m := NewMultiOutputReg[float64]()
+		m.Joint = true
+		if err := m.Fit(ds); err != nil {
+			t.Errorf("MultiOutputRegressor.Fit should not error : %v", err)
+			t.FailNow()
+		}
+
+		preds[i], _ = m.Predict([]float64{1.5})
+		report := m.PredictOn(ds)
+		reports[i] = report.Score + report.RootMeanSquareErr + report.MeanAbsoluteErr
+	}
+
+	for k := range preds[0] {
+		if math.Abs(preds[0][k]-preds[1][k]) > 1e-9 {
+			t.Errorf("Weighted prediction %d : %v != %v", k, preds[0][k], preds[1][k])
+		}
+	}
+
+	if math.Abs(reports[0]-reports[1]) > 1e-9 {
+		t.Errorf("Weighted report %v != %v", reports[0], reports[1])
+	}
+}
@@ -12,4 +12,17 @@ type RegressionReport[T constraints.Float] struct {
 	RootMeanSquareErr float64
 	MeanAbsoluteErr   float64
 	Score             float64
+	ActiveConstraints []string // constraints of the fit holding with equality at the solution
+}
+
+type MultiOutputReport[T constraints.Float] struct {
+	DataSet     *dataset.DataSet[T]
+	Targets     []string
+	PerTarget   []RegressionReport[T] // in the order of Targets
+	SkippedRows int                   // rows whose features could not be read
+
+	// averages of the per target metrics
+	RootMeanSquareErr float64
+	MeanAbsoluteErr   float64
+	Score             float64
 }